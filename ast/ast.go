@@ -7,7 +7,13 @@ import (
 	"unicode/utf8"
 )
 
+// All node types implement the Node interface.
+// Pos and End return the positions of the first character
+// belonging to the node and the first character immediately
+// after the node, as in package go/ast.
 type Node interface {
+	Pos() token.Pos
+	End() token.Pos
 	node()
 }
 
@@ -15,16 +21,204 @@ func (*AssignStmt) node()   {}
 func (*BasicLit) node()     {}
 func (*BinaryExpr) node()   {}
 func (*BlockStmt) node()    {}
+func (*BranchStmt) node()   {}
 func (*CallExpr) node()     {}
+func (*CaseClause) node()   {}
+func (*ConSpec) node()      {}
+func (*DeferStmt) node()    {}
 func (*ExprStmt) node()     {}
+func (*Field) node()        {}
+func (*File) node()         {}
+func (*ForStmt) node()      {}
 func (*FuncDecl) node()     {}
 func (*FuncLit) node()      {}
+func (*FuncType) node()     {}
+func (*GenDecl) node()      {}
 func (*Ident) node()        {}
 func (*IfStmt) node()       {}
+func (*ImportSpec) node()   {}
+func (*IncDecStmt) node()   {}
+func (*IndexExpr) node()    {}
+func (*KeyValue) node()     {}
+func (*LabeledStmt) node()  {}
+func (*ListLit) node()      {}
+func (*ListType) node()     {}
+func (*MapLit) node()       {}
+func (*MapType) node()      {}
 func (*Package) node()      {}
+func (*RecordLit) node()    {}
+func (*RecordType) node()   {}
 func (*ReturnStmt) node()   {}
 func (*SelectorExpr) node() {}
 func (*ShortFuncLit) node() {}
+func (*SliceExpr) node()    {}
+func (*SwitchStmt) node()   {}
+func (*TupleType) node()    {}
+func (*TypeDecl) node()     {}
+func (*UnaryExpr) node()    {}
+func (*ValueSpec) node()    {}
+
+func (x *AssignStmt) Pos() token.Pos   { return x.Lhs[0].Pos() }
+func (x *BasicLit) Pos() token.Pos     { return x.ValuePos }
+func (x *BinaryExpr) Pos() token.Pos   { return x.X.Pos() }
+func (x *BlockStmt) Pos() token.Pos    { return x.Lbrace }
+func (x *BranchStmt) Pos() token.Pos   { return x.TokPos }
+func (x *CallExpr) Pos() token.Pos     { return x.Fun.Pos() }
+func (x *DeferStmt) Pos() token.Pos    { return x.Defer }
+func (x *ExprStmt) Pos() token.Pos     { return x.X.Pos() }
+func (x *Field) Pos() token.Pos        { return x.Name.Pos() }
+func (x *File) Pos() token.Pos         { return x.Package }
+func (x *ForStmt) Pos() token.Pos      { return x.For }
+func (x *FuncDecl) Pos() token.Pos     { return x.Func }
+func (x *FuncLit) Pos() token.Pos      { return x.Func }
+func (x *FuncType) Pos() token.Pos     { return x.Func }
+func (x *Ident) Pos() token.Pos        { return x.NamePos }
+func (x *IfStmt) Pos() token.Pos       { return x.If }
+func (x *IncDecStmt) Pos() token.Pos   { return x.X.Pos() }
+func (x *IndexExpr) Pos() token.Pos    { return x.X.Pos() }
+func (x *KeyValue) Pos() token.Pos     { return x.Key.Pos() }
+func (x *LabeledStmt) Pos() token.Pos  { return x.Label.Pos() }
+func (x *ListLit) Pos() token.Pos      { return x.Lbrack }
+func (x *ListType) Pos() token.Pos     { return x.Lbrack }
+func (x *MapLit) Pos() token.Pos       { return x.Map }
+func (x *MapType) Pos() token.Pos      { return x.Map }
+func (x *Package) Pos() token.Pos      { return token.NoPos }
+func (x *RecordLit) Pos() token.Pos    { return x.Lbrace }
+func (x *RecordType) Pos() token.Pos   { return x.Lbrace }
+func (x *ReturnStmt) Pos() token.Pos   { return x.Return }
+func (x *SelectorExpr) Pos() token.Pos { return x.X.Pos() }
+func (x *ShortFuncLit) Pos() token.Pos { return x.And }
+func (x *SliceExpr) Pos() token.Pos    { return x.X.Pos() }
+func (x *SwitchStmt) Pos() token.Pos   { return x.Switch }
+func (x *TupleType) Pos() token.Pos    { return x.Lparen }
+func (x *TypeDecl) Pos() token.Pos     { return x.Type }
+func (x *UnaryExpr) Pos() token.Pos    { return x.OpPos }
+
+func (x *ImportSpec) Pos() token.Pos {
+	if x.Name != nil {
+		return x.Name.Pos()
+	}
+	return x.Path.Pos()
+}
+
+func (x *AssignStmt) End() token.Pos   { return x.Rhs[len(x.Rhs)-1].End() }
+func (x *BasicLit) End() token.Pos     { return token.Pos(int(x.ValuePos) + len(x.Value)) }
+func (x *BinaryExpr) End() token.Pos   { return x.Y.End() }
+func (x *BlockStmt) End() token.Pos    { return x.Rbrace + 1 }
+func (x *CallExpr) End() token.Pos     { return x.Rparen + 1 }
+func (x *DeferStmt) End() token.Pos    { return x.Call.End() }
+func (x *ExprStmt) End() token.Pos     { return x.X.End() }
+func (x *Field) End() token.Pos        { return x.Value.End() }
+func (x *ForStmt) End() token.Pos      { return x.Body.End() }
+func (x *FuncDecl) End() token.Pos     { return x.Body.End() }
+func (x *FuncLit) End() token.Pos      { return x.Body.End() }
+func (x *Ident) End() token.Pos        { return token.Pos(int(x.NamePos) + len(x.Name)) }
+func (x *ImportSpec) End() token.Pos   { return x.Path.End() }
+func (x *IncDecStmt) End() token.Pos   { return x.TokPos + 2 }
+func (x *IndexExpr) End() token.Pos    { return x.Rbrack + 1 }
+func (x *KeyValue) End() token.Pos     { return x.Value.End() }
+func (x *LabeledStmt) End() token.Pos  { return x.Stmt.End() }
+func (x *ListLit) End() token.Pos      { return x.Rbrack + 1 }
+func (x *ListType) End() token.Pos     { return x.Elem.End() }
+func (x *MapLit) End() token.Pos       { return x.Rbrace + 1 }
+func (x *MapType) End() token.Pos      { return x.Value.End() }
+func (x *Package) End() token.Pos      { return token.NoPos }
+func (x *RecordLit) End() token.Pos    { return x.Rbrace + 1 }
+func (x *RecordType) End() token.Pos   { return x.Rbrace + 1 }
+func (x *SelectorExpr) End() token.Pos { return x.Sel.End() }
+func (x *ShortFuncLit) End() token.Pos { return x.Body.End() }
+func (x *SliceExpr) End() token.Pos    { return x.Rbrack + 1 }
+func (x *SwitchStmt) End() token.Pos   { return x.Rbrace + 1 }
+func (x *TupleType) End() token.Pos    { return x.Rparen + 1 }
+func (x *TypeDecl) End() token.Pos     { return x.Rbrace + 1 }
+func (x *UnaryExpr) End() token.Pos    { return x.X.End() }
+
+func (x *File) End() token.Pos {
+	end := token.NoPos
+	if n := len(x.Funcs); n > 0 {
+		end = x.Funcs[n-1].End()
+	}
+	if n := len(x.Methods); n > 0 && x.Methods[n-1].End() > end {
+		end = x.Methods[n-1].End()
+	}
+	if n := len(x.Types); n > 0 && x.Types[n-1].End() > end {
+		end = x.Types[n-1].End()
+	}
+	if n := len(x.Values); n > 0 && x.Values[n-1].End() > end {
+		end = x.Values[n-1].End()
+	}
+	if end.IsValid() {
+		return end
+	}
+	if n := len(x.Imports); n > 0 {
+		return x.Imports[n-1].End()
+	}
+	return x.Name.End()
+}
+
+func (x *GenDecl) Pos() token.Pos { return x.TokPos }
+
+func (x *GenDecl) End() token.Pos {
+	if x.Rparen.IsValid() {
+		return x.Rparen + 1
+	}
+	return x.Specs[0].End()
+}
+
+func (x *ValueSpec) Pos() token.Pos { return x.Names[0].Pos() }
+
+func (x *ValueSpec) End() token.Pos {
+	if n := len(x.Values); n > 0 {
+		return x.Values[n-1].End()
+	}
+	return x.Names[len(x.Names)-1].End()
+}
+
+func (x *CaseClause) Pos() token.Pos { return x.Case }
+
+func (x *CaseClause) End() token.Pos {
+	if n := len(x.Body); n > 0 {
+		return x.Body[n-1].End()
+	}
+	return x.Colon + 1
+}
+
+func (x *ConSpec) Pos() token.Pos { return x.Name.Pos() }
+
+func (x *ConSpec) End() token.Pos {
+	if x.Rparen.IsValid() {
+		return x.Rparen + 1
+	}
+	return x.Name.End()
+}
+
+func (x *FuncType) End() token.Pos {
+	if x.Result != nil {
+		return x.Result.End()
+	}
+	return x.Rparen + 1
+}
+
+func (x *ReturnStmt) End() token.Pos {
+	if n := len(x.Results); n > 0 {
+		return x.Results[n-1].End()
+	}
+	return x.Return + 6 // len("return")
+}
+
+func (x *BranchStmt) End() token.Pos {
+	if x.Label != nil {
+		return x.Label.End()
+	}
+	return token.Pos(int(x.TokPos) + len(x.Tok.String()))
+}
+
+func (x *IfStmt) End() token.Pos {
+	if x.Else != nil {
+		return x.Else.End()
+	}
+	return x.Body.End()
+}
 
 type Expr interface {
 	Node
@@ -36,20 +230,37 @@ func (*BinaryExpr) exp()   {}
 func (*CallExpr) exp()     {}
 func (*FuncDecl) exp()     {}
 func (*FuncLit) exp()      {}
+func (*FuncType) exp()     {}
 func (*Ident) exp()        {}
+func (*IndexExpr) exp()    {}
+func (*ListLit) exp()      {}
+func (*ListType) exp()     {}
+func (*MapLit) exp()       {}
+func (*MapType) exp()      {}
+func (*RecordLit) exp()    {}
+func (*RecordType) exp()   {}
 func (*SelectorExpr) exp() {}
 func (*ShortFuncLit) exp() {}
+func (*SliceExpr) exp()    {}
+func (*TupleType) exp()    {}
+func (*UnaryExpr) exp()    {}
 
 type Stmt interface {
 	Node
 	stmt()
 }
 
-func (*AssignStmt) stmt() {}
-func (*BlockStmt) stmt()  {}
-func (*ExprStmt) stmt()   {}
-func (*IfStmt) stmt()     {}
-func (*ReturnStmt) stmt() {}
+func (*AssignStmt) stmt()  {}
+func (*BlockStmt) stmt()   {}
+func (*BranchStmt) stmt()  {}
+func (*DeferStmt) stmt()   {}
+func (*ExprStmt) stmt()    {}
+func (*ForStmt) stmt()     {}
+func (*IfStmt) stmt()      {}
+func (*IncDecStmt) stmt()  {}
+func (*LabeledStmt) stmt() {}
+func (*ReturnStmt) stmt()  {}
+func (*SwitchStmt) stmt()  {}
 
 type Package struct {
 	Name  string
@@ -57,39 +268,198 @@ type Package struct {
 }
 
 type File struct {
+	Package token.Pos // position of "package" keyword
 	Name    *Ident
 	Imports []*ImportSpec
 	Funcs   []*FuncDecl
+	Methods []*FuncDecl
+	Types   []*TypeDecl
+	Values  []*GenDecl // var and const declarations
 }
 
 type IfStmt struct {
+	If   token.Pos // position of "if" keyword
 	Cond Expr
 	Body *BlockStmt
 	Else Stmt // BlockStmt, IfStmt, or nil
 }
 
+// A ForStmt is a for loop.
+// Any of Init, Cond and Post may be nil.
+// If X is not nil, the loop ranges over X,
+// and Init, Cond and Post are nil.
+type ForStmt struct {
+	For  token.Pos // position of "for" keyword
+	Init Stmt
+	Cond Expr
+	Post Stmt
+
+	Key, Value Expr        // assigned by a range loop; nil if not given
+	Tok        token.Token // ASSIGN or DEFINE, or ILLEGAL if Key is nil
+	X          Expr        // value to range over
+
+	Body *BlockStmt
+}
+
+// A SwitchStmt is a switch statement.
+// A break statement in one of its cases
+// leaves the switch.
+type SwitchStmt struct {
+	Switch token.Pos // position of "switch" keyword
+	Tag    Expr
+	Lbrace token.Pos // position of "{"
+	Cases  []*CaseClause
+	Rbrace token.Pos // position of "}"
+}
+
+// A CaseClause is a case of a switch statement.
+// Each expression in List is a pattern:
+// an integer constant, a constructor,
+// or a constructor applied to names
+// to bind to its fields.
+type CaseClause struct {
+	Case  token.Pos // position of "case" or "default" keyword
+	List  []Expr    // nil means default case
+	Colon token.Pos // position of ":"
+	Body  []Stmt
+}
+
+// A BranchStmt is a break or continue statement.
+type BranchStmt struct {
+	TokPos token.Pos   // position of Tok
+	Tok    token.Token // BREAK or CONTINUE
+	Label  *Ident      // maybe nil
+}
+
+type LabeledStmt struct {
+	Label *Ident
+	Colon token.Pos // position of ":"
+	Stmt  Stmt
+}
+
+// An IncDecStmt is an increment or decrement statement.
+type IncDecStmt struct {
+	X      Expr
+	TokPos token.Pos   // position of Tok
+	Tok    token.Token // INC or DEC
+}
+
 type ImportSpec struct {
 	Name *Ident    // maybe nil
 	Path *BasicLit // import path (always a string)
 }
 
-type FuncDecl struct {
+// A TypeDecl declares a data type
+// as a union of constructors:
+//
+//	type Shape {
+//		Circle(r)
+//		Rect(w, h)
+//		Point
+//	}
+//
+// or an interface type, the type of
+// records of the given methods:
+//
+//	type Stringer interface {
+//		String() string
+//	}
+type TypeDecl struct {
+	Type      token.Pos // position of "type" keyword
+	Name      *Ident
+	Interface token.Pos // position of "interface" keyword; invalid for a data type
+	Lbrace    token.Pos // position of "{"
+	Cons      []*ConSpec
+	Methods   []*Field  // for an interface, each with a FuncType
+	Rbrace    token.Pos // position of "}"
+}
+
+// A GenDecl is a var or const declaration,
+// or a group of them in parentheses.
+type GenDecl struct {
+	TokPos token.Pos   // position of Tok
+	Tok    token.Token // VAR or CONST
+	Lparen token.Pos   // position of "("; invalid if not grouped
+	Specs  []*ValueSpec
+	Rparen token.Pos // position of ")"; invalid if not grouped
+}
+
+// A ValueSpec is one line of a GenDecl.
+// In a const group, Values may be empty,
+// meaning the values of the spec before it
+// are repeated, as in Go.
+type ValueSpec struct {
+	Names  []*Ident
+	Values []Expr
+}
+
+// A ConSpec is a constructor in a TypeDecl.
+type ConSpec struct {
 	Name   *Ident
-	Params []*Ident
-	Body   *BlockStmt
+	Params []*Ident  // names of fields
+	Rparen token.Pos // position of ")"; invalid if no params
+}
+
+// A FuncDecl declares a function,
+// or if Recv is not nil, a method
+// of the data type RecvType.
+type FuncDecl struct {
+	Func     token.Pos // position of "func" keyword
+	Recv     *Ident    // receiver; nil for a function
+	RecvType *Ident
+	Name     *Ident
+	Params   []*Ident
+	Types    []Expr    // type of each param, nil if not given; or nil
+	Ellipsis token.Pos // position of "..." after the last param; invalid if none
+	Result   Expr      // result type; nil if not given
+	Body     *BlockStmt
 }
 
 type ShortFuncLit struct {
+	And  token.Pos // position of "&"
 	Body Expr
 }
 
+// Params returns the params of x: x, y, and z,
+// as many as it needs to take the last one
+// its body refers to. A short func lit in the
+// body has its own params, and is not counted.
+func (x *ShortFuncLit) Params() []*Ident {
+	names := []string{"x", "y", "z"}
+	n := 0
+	Inspect(x.Body, func(node Node) bool {
+		switch node := node.(type) {
+		case *ShortFuncLit:
+			return false
+		case *Ident:
+			for i, name := range names {
+				if node.Name == name && i+1 > n {
+					n = i + 1
+				}
+			}
+		}
+		return true
+	})
+	params := make([]*Ident, n)
+	for i := range params {
+		params[i] = &Ident{NamePos: x.And, Name: names[i]}
+	}
+	return params
+}
+
 type FuncLit struct {
-	Params []*Ident
-	Body   *BlockStmt
+	Func     token.Pos // position of "func" keyword
+	Params   []*Ident
+	Types    []Expr    // type of each param, nil if not given; or nil
+	Ellipsis token.Pos // position of "..." after the last param; invalid if none
+	Result   Expr      // result type; nil if not given
+	Body     *BlockStmt
 }
 
 type BlockStmt struct {
-	List []Stmt
+	Lbrace token.Pos // position of "{"
+	List   []Stmt
+	Rbrace token.Pos // position of "}"
 }
 
 type Sequence struct {
@@ -97,23 +467,140 @@ type Sequence struct {
 }
 
 type ReturnStmt struct {
-	V Expr
+	Return  token.Pos // position of "return" keyword
+	Results []Expr    // maybe empty
+}
+
+// A DeferStmt defers a call until
+// the surrounding function exits.
+type DeferStmt struct {
+	Defer token.Pos // position of "defer" keyword
+	Call  *CallExpr
 }
 
 type CallExpr struct {
-	Fun  Expr
-	Args []Expr
+	Fun    Expr
+	Lparen token.Pos // position of "("
+	Args   []Expr
+	Rparen token.Pos // position of ")"
 }
 
 type BinaryExpr struct {
-	X  Expr
-	Op token.Token
-	Y  Expr
+	X     Expr
+	OpPos token.Pos // position of Op
+	Op    token.Token
+	Y     Expr
+}
+
+// A ListLit is a list literal: [a, b, c].
+type ListLit struct {
+	Lbrack token.Pos // position of "["
+	Elts   []Expr
+	Rbrack token.Pos // position of "]"
+}
+
+// A RecordLit is a record literal: {name: a, age: b}.
+type RecordLit struct {
+	Lbrace token.Pos // position of "{"
+	Fields []*Field
+	Rbrace token.Pos // position of "}"
+}
+
+// A Field is one name: value pair in a RecordLit.
+type Field struct {
+	Name  *Ident
+	Colon token.Pos // position of ":"
+	Value Expr
+}
+
+// A MapLit is a map literal: map{k1: a, k2: b}.
+type MapLit struct {
+	Map    token.Pos // position of "map"
+	Lbrace token.Pos // position of "{"
+	Elts   []*KeyValue
+	Rbrace token.Pos // position of "}"
+}
+
+// A KeyValue is one key: value pair in a MapLit.
+type KeyValue struct {
+	Key   Expr
+	Colon token.Pos // position of ":"
+	Value Expr
+}
+
+// Types in annotations are expressions too:
+// a type name is an Ident or a SelectorExpr.
+// The name _ stands for a type left to be inferred.
+// For a variadic param, the type given is that
+// of its elements, as in Go.
+
+// A ListType is a list type: []T.
+type ListType struct {
+	Lbrack token.Pos // position of "["
+	Elem   Expr
+}
+
+// A MapType is a map type: map[K]V.
+type MapType struct {
+	Map   token.Pos // position of "map"
+	Key   Expr
+	Value Expr
+}
+
+// A FuncType is a func type: func(T, U) R.
+type FuncType struct {
+	Func     token.Pos // position of "func" keyword
+	Params   []Expr
+	Ellipsis token.Pos // position of "..." before the last param; invalid if none
+	Rparen   token.Pos // position of ")"
+	Result   Expr      // nil if not given
+}
+
+// A RecordType is a record type: {name: T, age: U}.
+// If it ends in "...", records with other
+// fields besides these have the type too.
+type RecordType struct {
+	Lbrace   token.Pos // position of "{"
+	Fields   []*Field
+	Ellipsis token.Pos // position of "..."; invalid if none
+	Rbrace   token.Pos // position of "}"
+}
+
+// A TupleType is the result type of a func
+// returning several values: (T, U).
+type TupleType struct {
+	Lparen token.Pos // position of "("
+	Types  []Expr
+	Rparen token.Pos // position of ")"
+}
+
+type IndexExpr struct {
+	X      Expr
+	Lbrack token.Pos // position of "["
+	Index  Expr
+	Rbrack token.Pos // position of "]"
+}
+
+// A SliceExpr is x[Low:High], with either bound
+// possibly missing.
+type SliceExpr struct {
+	X      Expr
+	Lbrack token.Pos // position of "["
+	Low    Expr      // nil if missing
+	High   Expr      // nil if missing
+	Rbrack token.Pos // position of "]"
+}
+
+type UnaryExpr struct {
+	OpPos token.Pos // position of Op
+	Op    token.Token
+	X     Expr
 }
 
 type BasicLit struct {
-	Kind  token.Token
-	Value string // literal string
+	ValuePos token.Pos // literal position
+	Kind     token.Token
+	Value    string // literal string
 }
 
 // Returns the unquoted string value represented by b.
@@ -124,7 +611,8 @@ func (b *BasicLit) String() string {
 }
 
 type Ident struct {
-	Name string
+	NamePos token.Pos // identifier position
+	Name    string
 }
 
 func (id *Ident) IsExported() bool { return IsExported(id.Name) }
@@ -138,9 +626,10 @@ func IsExported(name string) bool {
 }
 
 type AssignStmt struct {
-	Lhs Expr
-	Tok token.Token
-	Rhs Expr
+	Lhs    []Expr
+	TokPos token.Pos // position of Tok
+	Tok    token.Token
+	Rhs    []Expr
 }
 
 type ExprStmt struct {
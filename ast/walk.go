@@ -0,0 +1,228 @@
+package ast
+
+// Inspect traverses an AST in depth-first order:
+// It starts by calling f(node); node must not be nil.
+// If f returns true, Inspect invokes f recursively
+// for each of the non-nil children of node.
+func Inspect(node Node, f func(Node) bool) {
+	if !f(node) {
+		return
+	}
+	switch n := node.(type) {
+	case *AssignStmt:
+		for _, x := range n.Lhs {
+			Inspect(x, f)
+		}
+		for _, x := range n.Rhs {
+			Inspect(x, f)
+		}
+	case *BasicLit:
+	case *BinaryExpr:
+		Inspect(n.X, f)
+		Inspect(n.Y, f)
+	case *BlockStmt:
+		for _, s := range n.List {
+			Inspect(s, f)
+		}
+	case *BranchStmt:
+		if n.Label != nil {
+			Inspect(n.Label, f)
+		}
+	case *CallExpr:
+		Inspect(n.Fun, f)
+		for _, x := range n.Args {
+			Inspect(x, f)
+		}
+	case *CaseClause:
+		for _, x := range n.List {
+			Inspect(x, f)
+		}
+		for _, s := range n.Body {
+			Inspect(s, f)
+		}
+	case *ConSpec:
+		Inspect(n.Name, f)
+		for _, p := range n.Params {
+			Inspect(p, f)
+		}
+	case *DeferStmt:
+		Inspect(n.Call, f)
+	case *ExprStmt:
+		Inspect(n.X, f)
+	case *Field:
+		Inspect(n.Name, f)
+		Inspect(n.Value, f)
+	case *File:
+		Inspect(n.Name, f)
+		for _, s := range n.Imports {
+			Inspect(s, f)
+		}
+		for _, d := range n.Types {
+			Inspect(d, f)
+		}
+		for _, d := range n.Values {
+			Inspect(d, f)
+		}
+		for _, d := range n.Funcs {
+			Inspect(d, f)
+		}
+		for _, d := range n.Methods {
+			Inspect(d, f)
+		}
+	case *ForStmt:
+		if n.Init != nil {
+			Inspect(n.Init, f)
+		}
+		if n.Cond != nil {
+			Inspect(n.Cond, f)
+		}
+		if n.Post != nil {
+			Inspect(n.Post, f)
+		}
+		if n.Key != nil {
+			Inspect(n.Key, f)
+		}
+		if n.Value != nil {
+			Inspect(n.Value, f)
+		}
+		if n.X != nil {
+			Inspect(n.X, f)
+		}
+		Inspect(n.Body, f)
+	case *FuncDecl:
+		if n.Recv != nil {
+			Inspect(n.Recv, f)
+			Inspect(n.RecvType, f)
+		}
+		Inspect(n.Name, f)
+		for _, p := range n.Params {
+			Inspect(p, f)
+		}
+		for _, x := range n.Types {
+			if x != nil {
+				Inspect(x, f)
+			}
+		}
+		if n.Result != nil {
+			Inspect(n.Result, f)
+		}
+		Inspect(n.Body, f)
+	case *FuncLit:
+		for _, p := range n.Params {
+			Inspect(p, f)
+		}
+		for _, x := range n.Types {
+			if x != nil {
+				Inspect(x, f)
+			}
+		}
+		if n.Result != nil {
+			Inspect(n.Result, f)
+		}
+		Inspect(n.Body, f)
+	case *FuncType:
+		for _, x := range n.Params {
+			Inspect(x, f)
+		}
+		if n.Result != nil {
+			Inspect(n.Result, f)
+		}
+	case *GenDecl:
+		for _, s := range n.Specs {
+			Inspect(s, f)
+		}
+	case *Ident:
+	case *IfStmt:
+		Inspect(n.Cond, f)
+		Inspect(n.Body, f)
+		if n.Else != nil {
+			Inspect(n.Else, f)
+		}
+	case *ImportSpec:
+		if n.Name != nil {
+			Inspect(n.Name, f)
+		}
+		Inspect(n.Path, f)
+	case *IncDecStmt:
+		Inspect(n.X, f)
+	case *IndexExpr:
+		Inspect(n.X, f)
+		Inspect(n.Index, f)
+	case *KeyValue:
+		Inspect(n.Key, f)
+		Inspect(n.Value, f)
+	case *LabeledStmt:
+		Inspect(n.Label, f)
+		Inspect(n.Stmt, f)
+	case *ListLit:
+		for _, x := range n.Elts {
+			Inspect(x, f)
+		}
+	case *ListType:
+		Inspect(n.Elem, f)
+	case *MapLit:
+		for _, x := range n.Elts {
+			Inspect(x, f)
+		}
+	case *MapType:
+		Inspect(n.Key, f)
+		Inspect(n.Value, f)
+	case *Package:
+		for _, file := range n.Files {
+			Inspect(file, f)
+		}
+	case *RecordLit:
+		for _, x := range n.Fields {
+			Inspect(x, f)
+		}
+	case *RecordType:
+		for _, x := range n.Fields {
+			Inspect(x, f)
+		}
+	case *ReturnStmt:
+		for _, x := range n.Results {
+			Inspect(x, f)
+		}
+	case *SelectorExpr:
+		Inspect(n.X, f)
+		Inspect(n.Sel, f)
+	case *ShortFuncLit:
+		Inspect(n.Body, f)
+	case *SliceExpr:
+		Inspect(n.X, f)
+		if n.Low != nil {
+			Inspect(n.Low, f)
+		}
+		if n.High != nil {
+			Inspect(n.High, f)
+		}
+	case *SwitchStmt:
+		Inspect(n.Tag, f)
+		for _, c := range n.Cases {
+			Inspect(c, f)
+		}
+	case *TupleType:
+		for _, x := range n.Types {
+			Inspect(x, f)
+		}
+	case *TypeDecl:
+		Inspect(n.Name, f)
+		for _, c := range n.Cons {
+			Inspect(c, f)
+		}
+		for _, m := range n.Methods {
+			Inspect(m, f)
+		}
+	case *UnaryExpr:
+		Inspect(n.X, f)
+	case *ValueSpec:
+		for _, x := range n.Names {
+			Inspect(x, f)
+		}
+		for _, x := range n.Values {
+			Inspect(x, f)
+		}
+	default:
+		panic("ast.Inspect: unexpected node type")
+	}
+}
@@ -2,13 +2,15 @@ package build
 
 import (
 	"errors"
+	"go/scanner"
 	"go/token"
 	"io"
 	"io/ioutil"
-	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/kr/bubble/ast"
 	"github.com/kr/bubble/cps"
@@ -16,12 +18,15 @@ import (
 	"github.com/kr/bubble/naivegen"
 	"github.com/kr/bubble/optimizer"
 	"github.com/kr/bubble/parser"
+	"github.com/kr/bubble/sem"
+	"github.com/kr/bubble/types"
 	"github.com/kr/pretty"
 )
 
 // mode flags
 const (
-	Debug = 1 << iota // print debug info to stderr
+	Debug   = 1 << iota // print debug info to stderr
+	BigInts             // make integers unbounded, at some cost in speed
 )
 
 var Mode int
@@ -33,22 +38,54 @@ var (
 
 type pkg struct {
 	importPath string
+	fset       *token.FileSet
 	*ast.Package
 }
 
+// Build compiles the program whose main package
+// is made of the named files, and writes the resulting
+// JavaScript to w.
+// If any file has syntax errors, the returned error
+// is a parser.ErrorList; other problems in the source
+// are reported as a scanner.ErrorList.
 func Build(w io.Writer, file ...string) error {
 	pkgs, err := parseProgram(file)
 	if err != nil {
-		log.Fatalln(err)
+		return err
 	}
 
 	pkgtab := make(map[string]fun.Tab)
 	tabf := func(s string) fun.Tab {
 		return pkgtab[s]
 	}
+	typtabf := func(s string) types.Tab {
+		return pkgtab[s].Types()
+	}
+	var fmode fun.Mode
+	var gmode naivegen.Mode
+	if Mode&BigInts != 0 {
+		fmode |= fun.BigInts
+		gmode |= naivegen.BigInts
+	}
 	var seq []fun.Exp
 	for _, p := range pkgs {
-		exp, ptab := fun.Convert(p.Package, tabf)
+		err := sem.Check(p.fset, p.Package, tabf)
+		if err != nil {
+			return err
+		}
+		info := &types.Info{
+			Methods:     make(map[*ast.SelectorExpr]*types.Method),
+			Conversions: make(map[ast.Expr][]*types.Method),
+			Numbers:     make(map[*ast.BasicLit]types.Basic),
+		}
+		ttab, err := types.Check(p.fset, p.Package, typtabf, info)
+		if err != nil {
+			return err
+		}
+		exp, ptab, err := fun.Convert(p.fset, p.Package, tabf, ttab, info, fmode)
+		if err != nil {
+			return err
+		}
 		pkgtab[p.importPath] = ptab
 		seq = append(seq, exp)
 		if Mode&Debug != 0 {
@@ -66,29 +103,26 @@ func Build(w io.Writer, file ...string) error {
 		pretty.Fprintf(os.Stderr, "opt % #v\n", cexp)
 	}
 
-	js := naivegen.Gen(cexp, r)
+	js := naivegen.Gen(cexp, r, gmode)
 	if Mode&Debug != 0 {
 		cmd := exec.Command("js-beautify", "-f", "-")
 		cmd.Stdout = os.Stderr
 		cmd.Stderr = os.Stderr
 		stdin, err := cmd.StdinPipe()
 		if err != nil {
-			log.Fatalln(err)
+			return err
 		}
 		err = cmd.Start()
 		_, err = io.WriteString(stdin, js)
 		if err != nil {
-			log.Fatalln(err)
+			return err
 		}
 		stdin.Close()
 		cmd.Wait()
 	}
 
 	_, err = io.WriteString(w, js)
-	if err != nil {
-		log.Fatalln(err)
-	}
-	return nil
+	return err
 }
 
 func parseProgram(files []string) ([]*pkg, error) {
@@ -97,34 +131,66 @@ func parseProgram(files []string) ([]*pkg, error) {
 		return nil, err
 	}
 	// TODO(kr): give main a valid import path
-	return parseDeps(main, nil)
+	return parseDeps(main, nil, nil)
 }
 
-func parseDeps(p *pkg, tab []*pkg) ([]*pkg, error) {
-	// TODO(kr): detect cycles
+// parseDeps returns tab followed by p and the packages
+// it depends on that are not already in tab,
+// each package after its dependencies.
+// Stack holds the import paths of the packages
+// whose dependencies are being parsed, to find cycles.
+func parseDeps(p *pkg, tab []*pkg, stack []string) ([]*pkg, error) {
+	stack = append(stack, p.importPath)
 	for _, f := range p.Files {
 		for _, spec := range f.Imports {
 			path := spec.Path.String()
+			pos := p.fset.Position(spec.Pos())
+			for i, s := range stack {
+				if s == path {
+					return nil, importCycle(pos, append(stack[i:], path))
+				}
+			}
 			if !containsPackage(tab, path) {
-				dep, err := parsePackage(path)
+				dep, err := parsePackage(pos, path)
 				if err != nil {
 					return nil, err
 				}
-				more, err := parseDeps(dep, tab)
+				tab, err = parseDeps(dep, tab, stack)
 				if err != nil {
 					return nil, err
 				}
-				tab = append(tab, more...)
 			}
 		}
 	}
 	return append(tab, p), nil
 }
 
-func parsePackage(path string) (*pkg, error) {
+// importCycle returns an error describing
+// the import cycle through the given paths,
+// where the last path is the same as the first,
+// at pos, the import that closes the cycle.
+func importCycle(pos token.Position, paths []string) error {
+	var a []string
+	for _, path := range paths {
+		a = append(a, strconv.Quote(path))
+	}
+	return errorAt(pos, "import cycle not allowed: "+strings.Join(a, " imports "))
+}
+
+// errorAt returns a scanner.ErrorList
+// holding the single error msg at pos.
+func errorAt(pos token.Position, msg string) error {
+	var list scanner.ErrorList
+	list.Add(pos, msg)
+	return list
+}
+
+// parsePackage parses the package with the
+// given import path, imported at pos.
+func parsePackage(pos token.Position, path string) (*pkg, error) {
 	names, err := packageFiles(path)
 	if err != nil {
-		return nil, err
+		return nil, errorAt(pos, err.Error())
 	}
 	p, err := parseFiles(names)
 	if err != nil {
@@ -158,7 +224,7 @@ func parseFiles(names []string) (*pkg, error) {
 	if Mode&Debug != 0 {
 		pretty.Fprintf(os.Stderr, "% #v\n", ast)
 	}
-	return &pkg{"", ast}, nil
+	return &pkg{"", fileSet, ast}, nil
 }
 
 func packageFiles(path string) ([]string, error) {
@@ -2,6 +2,7 @@ package cps
 
 import (
 	"log"
+	"sort"
 
 	"github.com/kr/bubble/fun"
 	"github.com/kr/bubble/prim"
@@ -35,8 +36,14 @@ func conv(exp fun.Exp, c func(Value) Exp) Exp {
 		return c(cpsvar(exp))
 	case fun.Int:
 		return c(Int(exp))
+	case fun.BigInt:
+		return c(BigInt(exp.String()))
+	case fun.Float:
+		return c(Float(exp))
 	case fun.String:
 		return c(String(exp))
+	case fun.Nil:
+		return c(Undef)
 	case fun.Prim:
 		panic("not implemented")
 	case fun.Record:
@@ -61,30 +68,38 @@ func conv(exp fun.Exp, c func(Value) Exp) Exp {
 		})
 	case fun.Switch:
 		if isBool(exp) {
-			return conv(exp.Value, func(v Value) Exp {
-				k := newVar("")
-				x := newVar("")
-				return Fix{
-					[]FixEnt{
-						{k, []Var{x}, c(x)},
-					},
-					Primop{
-						Op: prim.Ineq,
-						Vs: []Value{v, Int(0)},
-						Ws: nil,
-						Es: []Exp{
-							conv(exp.Default, func(z Value) Exp {
-								return App{k, []Value{z}}
-							}),
-							conv(exp.Cases[0].Body, func(z Value) Exp {
-								return App{k, []Value{z}}
-							}),
-						},
-					},
+			k := newVar("")
+			x := newVar("")
+			return Fix{
+				[]FixEnt{
+					{k, []Var{x}, c(x)},
+				},
+				convcond(
+					exp.Value,
+					conv(exp.Default, func(z Value) Exp {
+						return App{k, []Value{z}}
+					}),
+					conv(exp.Cases[0].Body, func(z Value) Exp {
+						return App{k, []Value{z}}
+					}),
+				),
+			}
+		}
+		k := newVar("")
+		x := newVar("")
+		join := func(z Value) Exp {
+			return App{k, []Value{z}}
+		}
+		return Fix{
+			[]FixEnt{
+				{k, []Var{x}, c(x)},
+			},
+			conv(exp.Value, func(v Value) Exp {
+				if len(exp.Poss) == 0 {
+					return convintswitch(exp, v, join)
 				}
-			})
-		} else {
-			panic("not implemented")
+				return convswitch(exp, v, join)
+			}),
 		}
 	case fun.App:
 		switch f := exp.F.(type) {
@@ -118,6 +133,26 @@ func conv(exp fun.Exp, c func(Value) Exp) Exp {
 						}
 					}),
 				}
+			case op.NArg() < 0:
+				// variadic; the arguments are in a record
+				A, ok := exp.V.(fun.Record)
+				if !ok {
+					panic("not implemented")
+				}
+				return fl(A, func(vs []Value) Exp {
+					if op.NRes() == 0 {
+						return Primop{op, vs, []Var{}, []Exp{c(Int(0))}}
+					}
+					w := newVar("")
+					return Primop{op, vs, []Var{w}, []Exp{c(w)}}
+				})
+			case op.NArg() == 0:
+				// the argument is an empty record
+				if op.NRes() == 0 {
+					return Primop{op, nil, []Var{}, []Exp{c(Int(0))}}
+				}
+				w := newVar("")
+				return Primop{op, nil, []Var{w}, []Exp{c(w)}}
 			case op.NArg() == 1 && op.NRes() == 0:
 				return conv(exp.V, func(v Value) Exp {
 					return Primop{
@@ -152,7 +187,41 @@ func conv(exp fun.Exp, c func(Value) Exp) Exp {
 				default:
 					panic("not implemented")
 				}
+			case op.Branch():
+				k := newVar("")
+				x := newVar("")
+				return Fix{
+					[]FixEnt{
+						{k, []Var{x}, c(x)},
+					},
+					convcond(exp, App{k, []Value{Int(1)}}, App{k, []Value{Int(0)}}),
+				}
+			case op.NArg() > 1 && op.NRes() == 0:
+				switch A := exp.V.(type) {
+				case fun.Record:
+					return fl(A, func(vs []Value) Exp {
+						return Primop{
+							op,
+							vs,
+							[]Var{},
+							[]Exp{c(Int(0))},
+						}
+					})
+				default:
+					panic("not implemented")
+				}
 			}
+		case fun.Fn:
+			// a let; convert the body in place, so that
+			// the variable is in scope for c
+			return conv(exp.V, func(e Value) Exp {
+				x := newVar("")
+				return Record{
+					[]RecordEnt{{e, Offp(0)}},
+					x,
+					Select{0, x, cpsvar(f.V), conv(f.Body, c)},
+				}
+			})
 		default:
 			r := newVar("")
 			x := newVar("")
@@ -188,6 +257,245 @@ func conv(exp fun.Exp, c func(Value) Exp) Exp {
 	panic("unreached")
 }
 
+// convswitch converts the cases of exp,
+// a switch on value v of a data type,
+// as its constructors are represented.
+// Constants are told from records with prim.Boxed,
+// if the type has both. The constants are a
+// jump table indexed by v, and tagged records
+// one indexed by their tag.
+// Each case proceeds with c.
+// Constructors with no case go to the default,
+// which is put in a continuation of its own
+// if more than one constructor needs it.
+func convswitch(exp fun.Switch, v Value, c func(Value) Exp) Exp {
+	var nconst, ntag int
+	untagged := false
+	for _, rep := range exp.Poss {
+		switch rep.(type) {
+		case fun.Constant:
+			nconst++
+		case fun.Tagged:
+			ntag++
+		default:
+			untagged = true
+		}
+	}
+	consts := make([]Exp, nconst)
+	tags := make([]Exp, ntag)
+	var rec Exp // for the untagged constructor
+	for _, cs := range exp.Cases {
+		con, ok := cs.Value.(fun.DataCon)
+		if !ok {
+			panic("not implemented")
+		}
+		var e *Exp
+		switch rep := con.Rep.(type) {
+		case fun.Constant:
+			e = &consts[rep]
+		case fun.Tagged:
+			e = &tags[rep]
+		default:
+			e = &rec
+		}
+		if *e == nil {
+			*e = conv(cs.Body, c)
+		}
+	}
+	var missing []*Exp
+	for i := range consts {
+		if consts[i] == nil {
+			missing = append(missing, &consts[i])
+		}
+	}
+	for i := range tags {
+		if tags[i] == nil {
+			missing = append(missing, &tags[i])
+		}
+	}
+	if untagged && rec == nil {
+		missing = append(missing, &rec)
+	}
+	def := exp.Default
+	if def == nil {
+		def = fun.Int(0)
+	}
+	var d Var
+	switch len(missing) {
+	case 0:
+	case 1:
+		*missing[0] = conv(def, c)
+	default:
+		d = newVar("")
+		for _, e := range missing {
+			*e = App{d, nil}
+		}
+	}
+
+	var constExp, boxedExp Exp
+	switch nconst {
+	case 0:
+	case 1:
+		constExp = consts[0]
+	default:
+		constExp = Switch{v, consts}
+	}
+	if untagged {
+		boxedExp = rec
+	} else if ntag > 0 {
+		t := newVar("")
+		boxedExp = Select{0, v, t, Switch{t, tags}}
+	}
+	var body Exp
+	switch {
+	case constExp == nil:
+		body = boxedExp
+	case boxedExp == nil:
+		body = constExp
+	default:
+		body = Primop{prim.Boxed, []Value{v}, nil, []Exp{boxedExp, constExp}}
+	}
+	if len(missing) < 2 {
+		return body
+	}
+	return Fix{
+		[]FixEnt{
+			{d, nil, conv(def, c)},
+		},
+		body,
+	}
+}
+
+// convintswitch converts exp, a switch on integer v
+// whose cases are IntCons. Each case proceeds with c.
+// If the case values are dense enough,
+// it becomes a jump table after a range check;
+// otherwise it is a binary search by comparison.
+func convintswitch(exp fun.Switch, v Value, c func(Value) Exp) Exp {
+	var cases []intcase
+	seen := make(map[int]bool)
+	for _, cs := range exp.Cases {
+		n := int(cs.Value.(fun.IntCon))
+		if !seen[n] {
+			seen[n] = true
+			cases = append(cases, intcase{n, conv(cs.Body, c)})
+		}
+	}
+	sort.Slice(cases, func(i, j int) bool { return cases[i].n < cases[j].n })
+	def := exp.Default
+	if def == nil {
+		def = fun.Int(0)
+	}
+	d := newVar("")
+	dj := App{d, nil}
+	var body Exp
+	if lo, hi := cases[0].n, cases[len(cases)-1].n; hi-lo < 2*len(cases) {
+		es := make([]Exp, hi-lo+1)
+		for i := range es {
+			es[i] = dj
+		}
+		for _, cs := range cases {
+			es[cs.n-lo] = cs.body
+		}
+		i := newVar("")
+		body = Primop{prim.Lt, []Value{v, Int(lo)}, nil, []Exp{
+			dj,
+			Primop{prim.Gt, []Value{v, Int(hi)}, nil, []Exp{
+				dj,
+				Primop{prim.Sub, []Value{v, Int(lo)}, []Var{i}, []Exp{
+					Switch{i, es},
+				}},
+			}},
+		}}
+	} else {
+		body = searchcases(cases, v, dj)
+	}
+	return Fix{
+		[]FixEnt{
+			{d, nil, conv(def, c)},
+		},
+		body,
+	}
+}
+
+// An intcase is a converted case of an integer switch.
+type intcase struct {
+	n    int
+	body Exp
+}
+
+// searchcases returns a binary search for v in cases,
+// which must be sorted, proceeding with def
+// if it is not there.
+func searchcases(cases []intcase, v Value, def Exp) Exp {
+	if len(cases) == 1 {
+		return Primop{prim.Eql, []Value{v, Int(cases[0].n)}, nil, []Exp{cases[0].body, def}}
+	}
+	m := len(cases) / 2
+	return Primop{prim.Lt, []Value{v, Int(cases[m].n)}, nil, []Exp{
+		searchcases(cases[:m], v, def),
+		searchcases(cases[m:], v, def),
+	}}
+}
+
+// convcond converts exp as the condition of a branch:
+// the result proceeds with t if exp is true (nonzero)
+// and with f otherwise.
+// A branch operation applied directly in exp
+// becomes the branch itself, without making a value,
+// and so do the boolean switches made for &&, || and !.
+func convcond(exp fun.Exp, t, f Exp) Exp {
+	switch exp := exp.(type) {
+	case fun.Int:
+		if exp != 0 {
+			return t
+		}
+		return f
+	case fun.Switch:
+		if isBool(exp) {
+			return jumps(t, f, func(t, f Exp) Exp {
+				return convcond(
+					exp.Value,
+					convcond(exp.Default, t, f),
+					convcond(exp.Cases[0].Body, t, f),
+				)
+			})
+		}
+	case fun.App:
+		if p, ok := exp.F.(fun.Prim); ok && prim.Op(p).Branch() {
+			if A, ok := exp.V.(fun.Record); ok {
+				return fl(A, func(vs []Value) Exp {
+					return Primop{prim.Op(p), vs, nil, []Exp{t, f}}
+				})
+			}
+		}
+	}
+	return conv(exp, func(v Value) Exp {
+		return Primop{prim.Ineq, []Value{v, Int(0)}, nil, []Exp{t, f}}
+	})
+}
+
+// jumps calls g with t and f, first replacing
+// each one that is not already an App
+// with an App of a new continuation,
+// so that g is free to duplicate them.
+func jumps(t, f Exp, g func(t, f Exp) Exp) Exp {
+	var fs []FixEnt
+	jump := func(e Exp) Exp {
+		if _, ok := e.(App); ok {
+			return e
+		}
+		k := newVar("")
+		fs = append(fs, FixEnt{k, nil, e})
+		return App{k, nil}
+	}
+	t, f = jump(t), jump(f)
+	if len(fs) == 0 {
+		return g(t, f)
+	}
+	return Fix{fs, g(t, f)}
+}
+
 func fixfnl(h []fun.Var, b []fun.Fn) (vs []FixEnt) {
 	if len(h) != len(b) {
 		panic("mismatch")
@@ -7,6 +7,8 @@ type Value interface {
 }
 
 func (*Label) value()    {}
+func (BigInt) value()    {}
+func (Float) value()     {}
 func (Int) value()       {}
 func (String) value()    {}
 func (Undefined) value() {}
@@ -27,6 +29,8 @@ func (Switch) cexp() {}
 
 type Label struct{ byte }
 type Int int
+type Float float64
+type BigInt string // decimal digits
 type String string
 type Undefined struct{}
 type Var struct {
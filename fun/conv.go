@@ -1,129 +1,1300 @@
 package fun
 
 import (
-	"log"
-	"strconv"
-
+	"fmt"
+	"go/scanner"
 	"go/token"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/kr/bubble/ast"
 	"github.com/kr/bubble/prim"
+	"github.com/kr/bubble/types"
 )
 
 // exported symbol table for a package
 type Tab struct {
-	name string
-	sym  map[string]Var
+	name    string
+	sym     map[string]Value
+	types   types.Tab
+	methods map[*ast.FuncDecl]function // of this package and those it imports
+}
+
+// Name returns the name of the package
+// that t describes.
+func (t Tab) Name() string {
+	return t.name
+}
+
+// Has returns whether the package exports name.
+func (t Tab) Has(name string) bool {
+	_, ok := t.sym[name]
+	return ok || t.types.Type(name)
+}
+
+// Names returns the names exported
+// by the package, in sorted order.
+func (t Tab) Names() []string {
+	var a []string
+	for name := range t.sym {
+		a = append(a, name)
+	}
+	a = append(a, t.types.TypeNames()...)
+	sort.Strings(a)
+	return a
+}
+
+// Types returns the types of the names
+// exported by the package, as checked by
+// package types, including the signatures
+// declared for its functions.
+func (t Tab) Types() types.Tab {
+	return t.types
+}
+
+// Type returns whether name is a data type
+// exported by the package.
+func (t Tab) Type(name string) bool {
+	return t.types.Type(name)
+}
+
+// Func returns the number of parameters of
+// function name exported by the package,
+// and whether it is variadic.
+// It returns ok false if there is no such function.
+func (t Tab) Func(name string) (nparam int, variadic, ok bool) {
+	f, ok := t.sym[name].(function)
+	return f.nparam, f.variadic, ok
+}
+
+// Con returns the data type and number of fields
+// of constructor name exported by the package.
+// It returns ok false if there is no such constructor.
+func (t Tab) Con(name string) (typ string, arity int, ok bool) {
+	c, ok := t.sym[name].(con)
+	return c.typ, c.arity, ok
+}
+
+// Var returns whether name is a variable
+// exported by the package.
+func (t Tab) Var(name string) bool {
+	_, ok := t.sym[name].(ref)
+	return ok
+}
+
+// Const returns whether name is a constant
+// exported by the package.
+func (t Tab) Const(name string) bool {
+	return isconst(t.sym[name])
+}
+
+// A Mode controls how Convert treats a program.
+type Mode uint
+
+const (
+	BigInts Mode = 1 << iota // integers are unbounded
+)
+
+type converter struct {
+	bigints bool // integers are unbounded
+
+	// The methods and conversions of the package
+	// being converted, and the functions of its
+	// methods and those of the packages it imports.
+	info    *types.Info
+	methods map[*ast.FuncDecl]function
+
+	litids map[ast.Node]Int // see litid
 }
 
 // Convert converts p to a functional expression.
 // Function pkgtab must return the symbol table
 // from a previous call to Convert
 // for any package imported by p.
-func Convert(p *ast.Package, pkgtab func(importPath string) Tab) (Exp, Tab) {
-	tab := Tab{p.Name, make(map[string]Var)}
-	fix := Fix{Body: Int(0)}
+// The returned symbol table holds ttab,
+// the types of the names p exports.
+// Info must hold the methods and conversions
+// recorded by types.Check for p.
+//
+// Positions in p are interpreted relative to fset.
+// If p cannot be converted, the returned error
+// is a scanner.ErrorList describing the problem.
+func Convert(fset *token.FileSet, p *ast.Package, pkgtab func(importPath string) Tab, ttab types.Tab, info *types.Info, mode Mode) (exp Exp, tab Tab, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			e, ok := v.(*posError)
+			if !ok {
+				panic(v)
+			}
+			err = scanner.ErrorList{{Pos: fset.Position(e.pos), Msg: e.msg}}
+		}
+	}()
+	tab = Tab{p.Name, make(map[string]Value), ttab, make(map[*ast.FuncDecl]function)}
+	cv := &converter{
+		bigints: mode&BigInts != 0,
+		info:    info,
+		methods: tab.methods,
+		litids:  make(map[ast.Node]Int),
+	}
+	for _, file := range p.Files {
+		for _, spec := range file.Imports {
+			for d, fv := range pkgtab(spec.Path.String()).methods {
+				cv.methods[d] = fv
+			}
+		}
+	}
+	fix := Fix{}
 	var inits []Var
+	var main Exp
 	r := globalEnv
 
-	// first define the environment r containing all funcs
+	// constructors of data types are bound first,
+	// each with a function that makes a value
+	for _, file := range p.Files {
+		for _, t := range file.Types {
+			poss := conreps(t)
+			for i, spec := range t.Cons {
+				c := con{spec.Name.Name, t.Name.Name, poss[i], poss, len(spec.Params), Var{}}
+				if c.arity > 0 {
+					c.fn = newVar(c.name)
+					v := newVar("")
+					var args []Exp
+					for j := range spec.Params {
+						args = append(args, Select{j, v})
+					}
+					check := App{Prim(prim.CheckArgs), Record{v, Int(c.arity)}}
+					fix.Names = append(fix.Names, c.fn)
+					fix.Fns = append(fix.Fns, Fn{v, seq([]Exp{check, convcon(c, args)})})
+				}
+				r = bind(r, c.name, c)
+				if spec.Name.IsExported() {
+					tab.sym[c.name] = c
+				}
+			}
+		}
+	}
+
+	// constants are folded to their values
+	r = cv.bindconsts(r, p, pkgtab, tab)
+
+	// each variable is a cell, made before anything else
+	var vars []*varinit
+	var cells []Var
+	for _, file := range p.Files {
+		for _, d := range file.Values {
+			if d.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range d.Specs {
+				v := &varinit{spec: spec, file: file, refs: make(refset)}
+				for _, id := range spec.Names {
+					var c Var
+					if id.Name != "_" {
+						c = newVar(id.Name)
+						cells = append(cells, c)
+						r = bind(r, id.Name, ref{c})
+						if id.IsExported() {
+							tab.sym[id.Name] = ref{c}
+						}
+					}
+					v.cells = append(v.cells, c)
+				}
+				vars = append(vars, v)
+			}
+		}
+	}
+
+	// then define the environment r containing all funcs
+	var checked []function
 	for _, file := range p.Files {
 		for _, f := range file.Funcs {
-			var v Var
-			if f.Name.Name == "init" {
-				v = newVar("init") // do not bind init
-				inits = append(inits, v)
+			name := f.Name.Name
+			fv := function{newVar(name), newVar(name), len(f.Params), f.Ellipsis.IsValid()}
+			if name == "init" {
+				inits = append(inits, fv.direct) // do not bind init
 			} else {
-				r, v = bindvar(r, f.Name)
+				r = bind(r, name, fv)
+				checked = append(checked, fv)
 			}
-			if p.Name == "main" && f.Name.Name == "main" {
-				fix.Body = App{v, Int(0)}
+			if p.Name == "main" && name == "main" {
+				main = App{fv.direct, Int(0)}
 			}
 			if f.Name.IsExported() {
-				tab.sym[f.Name.Name] = v
+				tab.sym[name] = fv
 			}
-			fix.Names = append(fix.Names, v)
+			fix.Names = append(fix.Names, fv.direct)
 		}
 	}
 
-	// then convert the funcs using r
+	// methods are functions taking the receiver first,
+	// not bound to any name
+	for _, file := range p.Files {
+		for _, f := range file.Methods {
+			fv := function{newVar(f.Name.Name), newVar(f.Name.Name), len(f.Params) + 1, f.Ellipsis.IsValid()}
+			cv.methods[f] = fv
+			fix.Names = append(fix.Names, fv.direct)
+		}
+	}
+
+	// then convert the funcs using r,
+	// noting what each one refers to
+	funcrefs := make(map[Var]refset)
 	for _, file := range p.Files {
 		r1 := bindimports(r, file.Imports, pkgtab)
 		for _, f := range file.Funcs {
-			fix.Fns = append(fix.Fns, convfunc(f.Params, f.Body, r1))
+			s := make(refset)
+			v := fix.Names[len(fix.Fns)]
+			funcrefs[v] = s
+			fn := cv.convfunc(f.Params, f.Body, funcid(v), track(r1, s))
+			cv.methodrefs(f.Body, s)
+			fix.Fns = append(fix.Fns, frame(p.Name+"."+f.Name.Name, fn))
 		}
 	}
+	for _, file := range p.Files {
+		r1 := bindimports(r, file.Imports, pkgtab)
+		for _, f := range file.Methods {
+			s := make(refset)
+			funcrefs[fix.Names[len(fix.Fns)]] = s
+			params := append([]*ast.Ident{f.Recv}, f.Params...)
+			fn := cv.convfunc(params, f.Body, funcid(cv.methods[f].direct), track(r1, s))
+			cv.methodrefs(f.Body, s)
+			fix.Fns = append(fix.Fns, frame(p.Name+"."+f.RecvType.Name+"."+f.Name.Name, fn))
+		}
+	}
+
+	// and make the versions that check their arguments,
+	// for calls that are not direct
+	for _, fv := range checked {
+		fix.Names = append(fix.Names, fv.v)
+		fix.Fns = append(fix.Fns, checkedfn(fv.direct, fv.nparam, fv.variadic))
+	}
 
+	// the program initializes the variables,
+	// runs each init func, then runs main
+	var body []Exp
+	for _, v := range vars {
+		r1 := track(bindimports(r, v.file.Imports, pkgtab), v.refs)
+		v.exp = cv.convvarinit(v, r1)
+		cv.methodrefs(v.spec, v.refs)
+	}
+	for _, v := range initorder(vars, funcrefs) {
+		body = append(body, v.exp)
+	}
 	for _, f := range inits {
-		fix.Body = App{Fn{newVar(""), fix.Body}, App{f, Int(0)}}
+		body = append(body, App{f, Int(0)})
+	}
+	if main != nil {
+		body = append(body, main)
+	}
+	fix.Body = seq(body)
+	exp = fix
+	for i := len(cells) - 1; i >= 0; i-- {
+		exp = App{Fn{cells[i], exp}, App{Prim(prim.Makeref), Int(0)}}
+	}
+	return exp, tab, nil
+}
+
+// A refset records the package-level variables
+// and functions that some code refers to.
+// Functions are recorded by their direct Var.
+type refset map[Var]bool
+
+// track returns an environment like r that
+// also records in s each package-level variable
+// or function looked up in r.
+// Names bound on top of the returned environment
+// shadow r, so they are not recorded.
+func track(r env, s refset) env {
+	return func(name string) Value {
+		v := r(name)
+		switch v := v.(type) {
+		case ref:
+			s[v.v] = true
+		case function:
+			s[v.direct] = true
+		}
+		return v
+	}
+}
+
+// methodrefs records in s each method that
+// node calls or converts a value to an interface
+// with, as found by types.Check. Methods are not
+// bound to names, so track cannot see them.
+func (cv *converter) methodrefs(node ast.Node, s refset) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		if x, ok := n.(*ast.SelectorExpr); ok && cv.info.Methods[x] != nil {
+			s[cv.methods[cv.info.Methods[x].Decl].direct] = true
+		}
+		if x, ok := n.(ast.Expr); ok {
+			for _, m := range cv.info.Conversions[x] {
+				s[cv.methods[m.Decl].direct] = true
+			}
+		}
+		return true
+	})
+}
+
+// A varinit is a var declaration at package level.
+type varinit struct {
+	spec  *ast.ValueSpec
+	file  *ast.File
+	cells []Var  // cell of each name, or the zero Var for _
+	refs  refset // what the initializer refers to
+	exp   Exp    // the converted initialization
+}
+
+// convvarinit converts the initialization
+// of the variables declared by v.
+// A value assigned to _ is evaluated and discarded.
+func (cv *converter) convvarinit(v *varinit, r env) Exp {
+	return cv.convvalues(v.spec.Values, len(v.cells), r, func(vals []Exp) Exp {
+		var el []Exp
+		for i, c := range v.cells {
+			if v.spec.Names[i].Name != "_" {
+				el = append(el, App{Prim(prim.Assign), Record{c, vals[i]}})
+			}
+		}
+		return seq(el)
+	})
+}
+
+// initorder returns vars in the order in which to
+// initialize them. As in Go, this is done by repeatedly
+// picking the earliest var in declaration order
+// that does not depend on an uninitialized var.
+// A var depends on the vars its initializer refers to,
+// directly or through functions, as given by funcrefs.
+func initorder(vars []*varinit, funcrefs map[Var]refset) []*varinit {
+	owner := make(map[Var]*varinit)
+	for _, v := range vars {
+		for _, c := range v.cells {
+			owner[c] = v
+		}
+	}
+	deps := make(map[*varinit]refset)
+	for _, v := range vars {
+		seen := make(refset)
+		var visit func(s refset)
+		visit = func(s refset) {
+			for x := range s {
+				if !seen[x] {
+					seen[x] = true
+					visit(funcrefs[x])
+				}
+			}
+		}
+		visit(v.refs)
+		deps[v] = seen
+	}
+
+	var order []*varinit
+	done := make(map[*varinit]bool)
+	ready := func(v *varinit) bool {
+		for x := range deps[v] {
+			if w, ok := owner[x]; ok && !done[w] {
+				return false
+			}
+		}
+		return true
 	}
-	return fix, tab
+	for len(order) < len(vars) {
+		var next *varinit
+		for _, v := range vars {
+			if !done[v] && ready(v) {
+				next = v
+				break
+			}
+		}
+		if next == nil {
+			for _, v := range vars {
+				if !done[v] {
+					id := v.spec.Names[0]
+					errorf(id.Pos(), "initialization cycle for %s", id.Name)
+				}
+			}
+		}
+		done[next] = true
+		order = append(order, next)
+	}
+	return order
 }
 
-func conv(node ast.Node, r env) Exp {
+// A constspec is one constant in a const declaration,
+// with the expression for its value, and the value
+// of iota in that expression.
+type constspec struct {
+	name *ast.Ident
+	x    ast.Expr
+	iota int
+	file *ast.File
+}
+
+// bindconsts evaluates the constants declared in p,
+// and returns r augmented with their values.
+// Exported constants are added to tab.
+func (cv *converter) bindconsts(r env, p *ast.Package, pkgtab func(string) Tab, tab Tab) env {
+	var specs []*constspec
+	byName := make(map[string]*constspec)
+	for _, file := range p.Files {
+		for _, d := range file.Values {
+			if d.Tok != token.CONST {
+				continue
+			}
+			var last []ast.Expr
+			for i, spec := range d.Specs {
+				if len(spec.Values) > 0 {
+					last = spec.Values
+				}
+				for j, id := range spec.Names {
+					if j >= len(last) {
+						errorf(id.Pos(), "missing init expr for const declaration")
+					}
+					c := &constspec{id, last[j], i, file}
+					specs = append(specs, c)
+					if id.Name != "_" {
+						byName[id.Name] = c
+					}
+				}
+			}
+		}
+	}
+
+	// Constants can refer to each other in any order,
+	// so each is evaluated when first needed.
+	vals := make(map[*constspec]Value)
+	busy := make(map[*constspec]bool)
+	var eval func(c *constspec) Value
+	rc := func(name string) Value {
+		if c, ok := byName[name]; ok {
+			return eval(c)
+		}
+		return r(name)
+	}
+	eval = func(c *constspec) Value {
+		if v, ok := vals[c]; ok {
+			return v
+		}
+		if busy[c] {
+			errorf(c.name.Pos(), "initialization cycle for %s", c.name.Name)
+		}
+		busy[c] = true
+		r1 := bindimports(rc, c.file.Imports, pkgtab)
+		vals[c] = cv.constval(c.x, bind(r1, "iota", Int(c.iota)))
+		return vals[c]
+	}
+	for _, c := range specs {
+		v := eval(c)
+		if c.name.Name == "_" {
+			continue
+		}
+		r = bind(r, c.name.Name, v)
+		if c.name.IsExported() {
+			tab.sym[c.name.Name] = v
+		}
+	}
+	return r
+}
+
+// constval evaluates x, a constant expression,
+// by the rules for numbers in package prim,
+// so the value is the same as if x were
+// evaluated at run time.
+// Comparisons and logical operators yield 1 or 0.
+func (cv *converter) constval(x ast.Expr, r env) Value {
+	switch x := x.(type) {
+	case *ast.BasicLit:
+		return cv.convlit(x)
+	case *ast.Ident:
+		if v := r(x.Name); isconst(v) {
+			return v
+		}
+	case *ast.SelectorExpr:
+		if id, ok := x.X.(*ast.Ident); ok {
+			if p, ok := r(id.Name).(pkg); ok && p.tab.Const(x.Sel.Name) {
+				return p.tab.sym[x.Sel.Name]
+			}
+		}
+	case *ast.CallExpr:
+		id, ok := x.Fun.(*ast.Ident)
+		if !ok || len(x.Args) != 1 {
+			break
+		}
+		op, _ := r(id.Name).(Prim)
+		v := cv.constval(x.Args[0], r)
+		f, isFloat := v.(Float)
+		switch prim.Op(op) {
+		case prim.ToInt:
+			if !isFloat {
+				return v
+			}
+			t := math.Trunc(float64(f))
+			if math.IsInf(t, 0) || math.IsNaN(t) {
+				errorf(x.Pos(), "constant overflow")
+			}
+			n, _ := new(big.Float).SetFloat64(t).Int(nil)
+			return cv.checkint(x, n)
+		case prim.ToFloat:
+			if g, ok := tofloat(v); ok {
+				return Float(g)
+			}
+		}
+	case *ast.UnaryExpr:
+		v := cv.constval(x.X, r)
+		if n, ok := bigint(v); ok {
+			switch x.Op {
+			case token.SUB:
+				return cv.checkint(x, n.Neg(n))
+			case token.NOT:
+				return boolval(n.Sign() == 0)
+			}
+		}
+		if f, ok := v.(Float); ok && x.Op == token.SUB {
+			return -f
+		}
+	case *ast.BinaryExpr:
+		a, b := cv.constval(x.X, r), cv.constval(x.Y, r)
+		if v := cv.constop(x, a, b); v != nil {
+			return v
+		}
+		errorf(x.OpPos, "invalid operation: operator %s in constant expression", x.Op)
+	}
+	errorf(x.Pos(), "constant expression required")
+	panic("unreached")
+}
+
+// constop applies the operator of x to a and b.
+// It returns nil if the operator does not apply.
+func (cv *converter) constop(x *ast.BinaryExpr, a, b Value) Value {
+	if s, ok := a.(String); ok {
+		t, ok := b.(String)
+		if !ok {
+			return nil
+		}
+		if x.Op == token.ADD {
+			return s + t
+		}
+		return compare(x.Op, s < t, s == t, s > t)
+	}
+	m, aInt := bigint(a)
+	n, bInt := bigint(b)
+	if aInt && bInt {
+		switch x.Op {
+		case token.ADD:
+			return cv.checkint(x, m.Add(m, n))
+		case token.SUB:
+			return cv.checkint(x, m.Sub(m, n))
+		case token.MUL:
+			return cv.checkint(x, m.Mul(m, n))
+		case token.QUO, token.REM:
+			if n.Sign() == 0 {
+				errorf(x.OpPos, "division by zero")
+			}
+			if x.Op == token.QUO {
+				return cv.checkint(x, m.Quo(m, n))
+			}
+			return cv.checkint(x, m.Rem(m, n))
+		case token.LAND:
+			return boolval(m.Sign() != 0 && n.Sign() != 0)
+		case token.LOR:
+			return boolval(m.Sign() != 0 || n.Sign() != 0)
+		}
+		c := m.Cmp(n)
+		return compare(x.Op, c < 0, c == 0, c > 0)
+	}
+	f, aNum := tofloat(a)
+	g, bNum := tofloat(b)
+	if !aNum || !bNum {
+		return nil
+	}
+	switch x.Op {
+	case token.ADD:
+		return Float(f + g)
+	case token.SUB:
+		return Float(f - g)
+	case token.MUL:
+		return Float(f * g)
+	case token.QUO:
+		return Float(f / g)
+	case token.REM:
+		return Float(math.Mod(f, g))
+	}
+	return compare(x.Op, f < g, f == g, f > g)
+}
+
+// compare returns the result of comparison op
+// between two values, given whether the first
+// is less than, equal to, or greater than the second.
+// It returns nil if op is not a comparison.
+func compare(op token.Token, lt, eq, gt bool) Value {
+	switch op {
+	case token.EQL:
+		return boolval(eq)
+	case token.NEQ:
+		return boolval(!eq)
+	case token.LSS:
+		return boolval(lt)
+	case token.LEQ:
+		return boolval(lt || eq)
+	case token.GTR:
+		return boolval(gt)
+	case token.GEQ:
+		return boolval(gt || eq)
+	}
+	return nil
+}
+
+// MaxInt is the largest integer that
+// is not a BigInt, as described in package prim.
+const MaxInt = 1<<53 - 1
+
+var (
+	maxInt = big.NewInt(MaxInt)
+	minInt = big.NewInt(-MaxInt)
+)
+
+// intval returns the value of integer n:
+// an Int if it is small enough, and otherwise a BigInt.
+func intval(n *big.Int) Value {
+	if n.Cmp(minInt) >= 0 && n.Cmp(maxInt) <= 0 {
+		return Int(n.Int64())
+	}
+	return BigInt{n}
+}
+
+// checkint returns the value of n, the result of x,
+// or reports an error if it overflows.
+func (cv *converter) checkint(x ast.Expr, n *big.Int) Value {
+	v := intval(n)
+	if _, ok := v.(BigInt); ok && !cv.bigints {
+		errorf(x.Pos(), "constant overflow")
+	}
+	return v
+}
+
+// bigint returns a new big.Int holding the value of v,
+// if v is an integer.
+func bigint(v Value) (*big.Int, bool) {
+	switch v := v.(type) {
+	case Int:
+		return big.NewInt(int64(v)), true
+	case BigInt:
+		return new(big.Int).Set(v.Int), true
+	}
+	return nil, false
+}
+
+// tofloat returns the value of v, a number,
+// as a float, rounded to nearest as in JavaScript.
+func tofloat(v Value) (float64, bool) {
+	switch v := v.(type) {
+	case Int:
+		return float64(v), true
+	case BigInt:
+		f, _ := new(big.Float).SetInt(v.Int).Float64()
+		return f, true
+	case Float:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func boolval(b bool) Value {
+	if b {
+		return Int(1)
+	}
+	return Int(0)
+}
+
+// isconst returns whether v is the value
+// of a constant.
+func isconst(v Value) bool {
+	switch v.(type) {
+	case Int, BigInt, Float, String:
+		return true
+	}
+	return false
+}
+
+// A posError is a problem found in the source
+// during conversion. It is panicked by errorf
+// and recovered by Convert.
+type posError struct {
+	pos token.Pos
+	msg string
+}
+
+func errorf(pos token.Pos, format string, v ...interface{}) {
+	panic(&posError{pos, fmt.Sprintf(format, v...)})
+}
+
+// conv converts node. If node is a value
+// converted to an interface, the result is
+// the record of its methods.
+func (cv *converter) conv(node ast.Node, r env) Exp {
+	if x, ok := node.(ast.Expr); ok {
+		if ms, ok := cv.info.Conversions[x]; ok {
+			return cv.convmethods(ms, cv.convnode(x, r))
+		}
+	}
+	return cv.convnode(node, r)
+}
+
+func (cv *converter) convnode(node ast.Node, r env) Exp {
 	switch node := node.(type) {
 	case *ast.Ident:
 		v := r(node.Name)
-		if _, ok := v.(pkg); ok {
-			log.Fatal("use of package " + node.Name + " without selector")
+		switch v.(type) {
+		case nil:
+			errorf(node.Pos(), "undefined: %s", node.Name)
+		case pkg:
+			errorf(node.Pos(), "use of package %s without selector", node.Name)
 		}
-		return v
+		return convval(v)
 	case *ast.BasicLit:
-		return convlit(node.Kind, node.Value)
+		return cv.convlit(node)
 	case *ast.CallExpr:
-		return App{conv(node.Fun, r), Record(convl(node.Args, r))}
+		if c, ok := lookupcon(node.Fun, r); ok && c.arity > 0 {
+			if len(node.Args) != c.arity {
+				errorf(node.Pos(), "wrong number of arguments to %s: have %d, want %d", c.name, len(node.Args), c.arity)
+			}
+			return convcon(c, cv.convl(node.Args, r))
+		}
+		if fv, ok := lookupfunc(node.Fun, r); ok {
+			return convdirectcall(node, fv, cv.convl(node.Args, r))
+		}
+		if sel, ok := node.Fun.(*ast.SelectorExpr); ok && cv.info.Methods[sel] != nil {
+			fv := cv.methods[cv.info.Methods[sel].Decl]
+			return convdirectcall(node, fv, append([]Exp{cv.conv(sel.X, r)}, cv.convl(node.Args, r)...))
+		}
+		if isconversion(node, r) {
+			return cv.conv(node.Args[0], r)
+		}
+		f := cv.conv(node.Fun, r)
+		if p, ok := f.(Prim); ok && prim.Op(p) == prim.Recover {
+			id, _ := r("func").(Int)
+			return convrecover(node, id)
+		}
+		if p, ok := f.(Prim); ok {
+			return convprimcall(node, prim.Op(p), cv.convl(node.Args, r))
+		}
+		return App{f, Record(cv.convl(node.Args, r))}
+	case *ast.ListLit:
+		return App{Prim(prim.List), Record(cv.convl(node.Elts, r))}
+	case *ast.MapLit:
+		var el []Exp
+		for _, kv := range node.Elts {
+			el = append(el, cv.conv(kv.Key, r), cv.conv(kv.Value, r))
+		}
+		return App{Prim(prim.MakeMap), Record(el)}
+	case *ast.IndexExpr:
+		return App{Prim(prim.Index), Record{cv.conv(node.X, r), cv.conv(node.Index, r)}}
+	case *ast.SliceExpr:
+		// A missing high bound is the length of x.
+		x := newVar("")
+		var lo, hi Exp = Int(0), App{Prim(prim.Len), x}
+		if node.Low != nil {
+			lo = cv.conv(node.Low, r)
+		}
+		if node.High != nil {
+			hi = cv.conv(node.High, r)
+		}
+		return let([]binding{{x, cv.conv(node.X, r)}}, App{Prim(prim.Slice), Record{x, lo, hi}})
+	case *ast.RecordLit:
+		// The first element is the record's shape,
+		// a list of its field names, in sorted order
+		// so that records with the same fields are
+		// alike, as == needs. The values are still
+		// evaluated in the order they are written.
+		names := fieldNames(node)
+		vals := make(map[string]Var)
+		var binds []binding
+		for _, f := range node.Fields {
+			v := newVar(f.Name.Name)
+			binds = append(binds, binding{v, cv.conv(f.Value, r)})
+			vals[f.Name.Name] = v
+		}
+		rec := Record{String(strings.Join(names, ","))}
+		for _, name := range names {
+			rec = append(rec, vals[name])
+		}
+		return let(binds, rec)
 	case *ast.BinaryExpr:
-		el := []Exp{conv(node.X, r), conv(node.Y, r)}
+		switch node.Op {
+		case token.LAND:
+			return Switch{
+				Value:   cv.conv(node.X, r),
+				Cases:   []Case{{IntCon(0), Int(0)}},
+				Default: cv.conv(node.Y, r),
+			}
+		case token.LOR:
+			return Switch{
+				Value:   cv.conv(node.X, r),
+				Cases:   []Case{{IntCon(0), cv.conv(node.Y, r)}},
+				Default: Int(1),
+			}
+		}
+		el := []Exp{cv.conv(node.X, r), cv.conv(node.Y, r)}
 		return App{convprim(node.Op), Record(el)}
+	case *ast.UnaryExpr:
+		switch node.Op {
+		case token.SUB:
+			return App{Prim(prim.Neg), cv.conv(node.X, r)}
+		case token.NOT:
+			return Switch{
+				Value:   cv.conv(node.X, r),
+				Cases:   []Case{{IntCon(0), Int(1)}},
+				Default: Int(0),
+			}
+		}
+		errorf(node.Pos(), "unhandled operator %v", node.Op)
 	case *ast.FuncLit:
-		return convfunc(node.Params, node.Body, r)
+		f := cv.convfunc(node.Params, node.Body, cv.litid(node), r)
+		return checkedfn(f, len(node.Params), node.Ellipsis.IsValid())
 	case *ast.BlockStmt:
-		return convseq(node.List, r)
+		return cv.convseq(node.List, r)
 	case *ast.IfStmt:
 		var alt Exp = Int(0)
 		if node.Else != nil {
-			alt = conv(node.Else, r)
+			alt = cv.conv(node.Else, r)
 		}
 		return Switch{
-			Value:   conv(node.Cond, r),
+			Value:   cv.conv(node.Cond, r),
 			Cases:   []Case{{IntCon(0), alt}},
-			Default: conv(node.Body, r),
+			Default: cv.conv(node.Body, r),
 		}
 	case *ast.ExprStmt:
-		return conv(node.X, r)
+		return cv.conv(node.X, r)
+	case *ast.DeferStmt:
+		return cv.convdefer(node, r)
 	case *ast.ReturnStmt:
-		return App{r("return"), Record{conv(node.V, r)}}
+		// Several results are returned as a tuple.
+		var v Exp = Int(0)
+		switch len(node.Results) {
+		case 0:
+		case 1:
+			v = cv.conv(node.Results[0], r)
+		default:
+			v = Record(cv.convl(node.Results, r))
+		}
+		return App{r("return"), Record{v}}
+	case *ast.AssignStmt:
+		if node.Tok != token.ASSIGN {
+			errorf(node.Pos(), "unhandled %T", node)
+		}
+		if len(node.Lhs) == 1 && len(node.Rhs) == 1 {
+			switch x := node.Lhs[0].(type) {
+			case *ast.IndexExpr:
+				el := []Exp{cv.conv(x.X, r), cv.conv(x.Index, r), cv.conv(node.Rhs[0], r)}
+				return App{Prim(prim.SetIndex), Record(el)}
+			case *ast.Ident:
+				if x.Name == "_" {
+					return cv.conv(node.Rhs[0], r)
+				}
+				return convassign(x, cv.conv(node.Rhs[0], r), r)
+			}
+			errorf(node.Pos(), "cannot assign to expression")
+		}
+		// Operands of index expressions on the left
+		// and then values on the right are evaluated
+		// before any assignment, as in Go.
+		var binds []binding
+		var sets []func(Exp) Exp
+		for _, x := range node.Lhs {
+			switch x := x.(type) {
+			case *ast.IndexExpr:
+				a, i := newVar(""), newVar("")
+				binds = append(binds, binding{a, cv.conv(x.X, r)}, binding{i, cv.conv(x.Index, r)})
+				sets = append(sets, func(v Exp) Exp {
+					return App{Prim(prim.SetIndex), Record{a, i, v}}
+				})
+			case *ast.Ident:
+				if x.Name == "_" {
+					sets = append(sets, nil)
+					break
+				}
+				sets = append(sets, func(v Exp) Exp {
+					return convassign(x, v, r)
+				})
+			default:
+				errorf(x.Pos(), "cannot assign to expression")
+			}
+		}
+		return let(binds, cv.convvalues(node.Rhs, len(sets), r, func(vals []Exp) Exp {
+			var el []Exp
+			for i, set := range sets {
+				if set != nil {
+					el = append(el, set(vals[i]))
+				}
+			}
+			return seq(el)
+		}))
+	case *ast.IncDecStmt:
+		op := token.ADD
+		if node.Tok == token.DEC {
+			op = token.SUB
+		}
+		one := &ast.BasicLit{ValuePos: node.TokPos, Kind: token.INT, Value: "1"}
+		if x, ok := node.X.(*ast.IndexExpr); ok {
+			// A missing map key counts as 0.
+			a, i := newVar(""), newVar("")
+			binds := []binding{{a, cv.conv(x.X, r)}, {i, cv.conv(x.Index, r)}}
+			v := App{convprim(op), Record{App{Prim(prim.IndexZero), Record{a, i}}, cv.convlit(one)}}
+			return let(binds, App{Prim(prim.SetIndex), Record{a, i, v}})
+		}
+		return cv.conv(&ast.AssignStmt{
+			Lhs:    []ast.Expr{node.X},
+			TokPos: node.TokPos,
+			Tok:    token.ASSIGN,
+			Rhs:    []ast.Expr{&ast.BinaryExpr{X: node.X, OpPos: node.TokPos, Op: op, Y: one}},
+		}, r)
+	case *ast.ForStmt:
+		return cv.convfor(node, nil, r)
+	case *ast.SwitchStmt:
+		return cv.convswitch(node, nil, r)
+	case *ast.LabeledStmt:
+		switch s := node.Stmt.(type) {
+		case *ast.ForStmt:
+			return cv.convfor(s, node.Label, r)
+		case *ast.SwitchStmt:
+			return cv.convswitch(s, node.Label, r)
+		}
+		return cv.conv(node.Stmt, r)
+	case *ast.BranchStmt:
+		name := node.Tok.String()
+		if node.Label != nil {
+			name += " " + node.Label.Name
+		}
+		k := r(name)
+		if k == nil {
+			errorf(node.Pos(), "invalid %s", name)
+		}
+		return App{k, Record{Int(0)}}
 	case *ast.SelectorExpr:
+		if m := cv.info.Methods[node]; m != nil {
+			return boundfn(cv.methods[m.Decl], cv.conv(node.X, r))
+		}
 		// If node.X is a package, don't call conv.
 		// A package is not a valid expression.
 		if id, ok := node.X.(*ast.Ident); ok {
-			if p, ok := r(id.Name).(pkg); ok {
-				return p.tab.sym[node.Sel.Name]
+			switch v := r(id.Name).(type) {
+			case pkg:
+				return convval(v.tab.sym[node.Sel.Name])
+			case record:
+				return Select{fieldIndex(node, v.fields), v.v}
 			}
 		}
-		log.Fatalf("cannot select from non-package %v", node.X)
+		if x, ok := node.X.(*ast.RecordLit); ok {
+			return Select{fieldIndex(node, fieldNames(x)), cv.conv(x, r)}
+		}
+		sel := Record{cv.conv(node.X, r), String(node.Sel.Name)}
+		return App{Prim(prim.Field), sel}
 	case *ast.ShortFuncLit:
-		params := []*ast.Ident{{"x"}, {"y"}, {"z"}}
-		return convfunc(params, node.Body, r)
+		params := node.Params()
+		f := cv.convfunc(params, node.Body, cv.litid(node), r)
+		return checkedfn(f, len(params), false)
 	default:
-		log.Fatalf("unhandled %T", node)
+		errorf(node.Pos(), "unhandled %T", node)
 	}
 	panic("unreached")
 }
 
+// conreps returns the representation of
+// each constructor of data type t.
+// See Conrep.
+func conreps(t *ast.TypeDecl) []Conrep {
+	boxed := 0
+	for _, spec := range t.Cons {
+		if len(spec.Params) > 0 {
+			boxed++
+		}
+	}
+	var poss []Conrep
+	var nconst, ntag int
+	for _, spec := range t.Cons {
+		switch {
+		case len(spec.Params) == 0:
+			poss = append(poss, Constant(nconst))
+			nconst++
+		case boxed == 1:
+			poss = append(poss, Untagged)
+		default:
+			poss = append(poss, Tagged(ntag))
+			ntag++
+		}
+	}
+	return poss
+}
+
+// convcon converts an application of c
+// to the given fields.
+func convcon(c con, fields []Exp) Exp {
+	switch rep := c.rep.(type) {
+	case Constant:
+		return Int(rep)
+	case Tagged:
+		return append(Record{Int(rep)}, fields...)
+	}
+	return Record(fields)
+}
+
+// fieldOffset returns the index of the first field
+// in the record for a value of representation rep.
+func fieldOffset(rep Conrep) int {
+	if _, ok := rep.(Tagged); ok {
+		return 1
+	}
+	return 0
+}
+
+// lookupfunc returns the top-level function that x refers to,
+// if x is a name or a package selection
+// that refers to one.
+func lookupfunc(x ast.Expr, r env) (function, bool) {
+	switch x := x.(type) {
+	case *ast.Ident:
+		fv, ok := r(x.Name).(function)
+		return fv, ok
+	case *ast.SelectorExpr:
+		if id, ok := x.X.(*ast.Ident); ok {
+			if p, ok := r(id.Name).(pkg); ok {
+				fv, ok := p.tab.sym[x.Sel.Name].(function)
+				return fv, ok
+			}
+		}
+	}
+	return function{}, false
+}
+
+// convdirectcall converts call, a call of
+// top-level function fv with the given arguments,
+// whose number is checked here instead of at run time.
+// For a method, the receiver is the first argument.
+// Arguments for the variadic parameter,
+// if any, are collected in a list.
+func convdirectcall(call *ast.CallExpr, fv function, args []Exp) Exp {
+	n := fv.nparam
+	switch {
+	case fv.variadic && len(args) < n-1:
+		errorf(call.Pos(), "wrong number of arguments to %s: have %d, want at least %d", fv.v.Name, len(args), n-1)
+	case fv.variadic:
+		rest := App{Prim(prim.List), Record(args[n-1:])}
+		args = append(args[:n-1:n-1], rest)
+	case len(args) != n:
+		errorf(call.Pos(), "wrong number of arguments to %s: have %d, want %d", fv.v.Name, len(args), n)
+	}
+	return App{fv.direct, Record(args)}
+}
+
+// isconversion returns whether call is
+// a conversion I(x) to an interface type.
+// Types are the only names package sem
+// lets through that are not bound in r.
+func isconversion(call *ast.CallExpr, r env) bool {
+	switch x := call.Fun.(type) {
+	case *ast.Ident:
+		return r(x.Name) == nil
+	case *ast.SelectorExpr:
+		if id, ok := x.X.(*ast.Ident); ok {
+			if p, ok := r(id.Name).(pkg); ok {
+				return p.tab.Type(x.Sel.Name)
+			}
+		}
+	}
+	return false
+}
+
+// boundfn returns a function that calls method fv
+// with the value of recv, evaluated once, followed
+// by the arguments in its argument record,
+// whose number it checks as checkedfn does.
+func boundfn(fv function, recv Exp) Exp {
+	x, a := newVar(""), newVar("")
+	n := fv.nparam - 1
+	args := Record{x}
+	if !fv.variadic {
+		for i := 0; i < n; i++ {
+			args = append(args, Select{i, a})
+		}
+		check := App{Prim(prim.CheckArgs), Record{a, Int(n)}}
+		return let([]binding{{x, recv}}, Fn{a, seq([]Exp{check, App{fv.direct, args}})})
+	}
+	for i := 0; i < n-1; i++ {
+		args = append(args, Select{i, a})
+	}
+	args = append(args, App{Prim(prim.Rest), Record{a, Int(n - 1)}})
+	return let([]binding{{x, recv}}, Fn{a, App{fv.direct, args}})
+}
+
+// convmethods converts the conversion of x,
+// a value of a data type, to an interface:
+// a record of methods ms bound to x.
+// As in a record literal, the first element
+// is the record's shape.
+func (cv *converter) convmethods(ms []*types.Method, x Exp) Exp {
+	v := newVar("")
+	var names []string
+	var fns []Exp
+	for _, m := range ms {
+		names = append(names, m.Name)
+		fns = append(fns, boundfn(cv.methods[m.Decl], v))
+	}
+	rec := append(Record{String(strings.Join(names, ","))}, fns...)
+	return let([]binding{{v, x}}, rec)
+}
+
+// checkedfn returns a function that checks
+// the number of arguments in its argument record
+// before calling f, which takes n params.
+// If variadic is set, f's last param is a list
+// of the arguments after the first n-1.
+func checkedfn(f Exp, n int, variadic bool) Fn {
+	a := newVar("")
+	if !variadic {
+		check := App{Prim(prim.CheckArgs), Record{a, Int(n)}}
+		return Fn{a, seq([]Exp{check, App{f, a}})}
+	}
+	rest := newVar("")
+	var args Record
+	for i := 0; i < n-1; i++ {
+		args = append(args, Select{i, a})
+	}
+	args = append(args, rest)
+	return Fn{a, let([]binding{{rest, App{Prim(prim.Rest), Record{a, Int(n - 1)}}}}, App{f, args})}
+}
+
+// convconref converts a reference to c other than a call:
+// a constructor with no fields is a value,
+// and one with fields is a function.
+func convconref(c con) Exp {
+	if c.arity == 0 {
+		return convcon(c, nil)
+	}
+	return c.fn
+}
+
+// lookupcon returns the constructor that x refers to,
+// if x is a name or a package selection
+// that refers to a constructor.
+func lookupcon(x ast.Expr, r env) (con, bool) {
+	switch x := x.(type) {
+	case *ast.Ident:
+		c, ok := r(x.Name).(con)
+		return c, ok
+	case *ast.SelectorExpr:
+		if id, ok := x.X.(*ast.Ident); ok {
+			if p, ok := r(id.Name).(pkg); ok {
+				c, ok := p.tab.sym[x.Sel.Name].(con)
+				return c, ok
+			}
+		}
+	}
+	return con{}, false
+}
+
+// fieldNames returns the names of the fields
+// of record literal x, in sorted order.
+func fieldNames(x *ast.RecordLit) (names []string) {
+	for _, f := range x.Fields {
+		names = append(names, f.Name.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fieldIndex returns the offset of the field selected by x
+// in a record with the given field names.
+// The record's shape is at offset 0.
+func fieldIndex(x *ast.SelectorExpr, names []string) int {
+	for i, name := range names {
+		if name == x.Sel.Name {
+			return i + 1
+		}
+	}
+	errorf(x.Sel.Pos(), "record has no field %s", x.Sel.Name)
+	panic("unreached")
+}
+
+// convprimcall converts call, a direct call
+// of the builtin operation op with the given arguments.
+// Variadic operations get their arguments in a record;
+// others get them as they would for App of a Prim,
+// a single argument by itself and several in a record.
+func convprimcall(call *ast.CallExpr, op prim.Op, args []Exp) Exp {
+	switch n := op.NArg(); {
+	case n < 0:
+		return App{Prim(op), Record(args)}
+	case n != len(args):
+		errorf(call.Pos(), "wrong number of arguments to %s: have %d, want %d", call.Fun.(*ast.Ident).Name, len(args), n)
+	case n == 1:
+		return App{Prim(op), args[0]}
+	}
+	return App{Prim(op), Record(args)}
+}
+
+// convrecover converts call, a call of recover
+// directly in the function with the given id,
+// or 0 if it is not directly in a function.
+func convrecover(call *ast.CallExpr, id Int) Exp {
+	if len(call.Args) != 0 {
+		errorf(call.Pos(), "wrong number of arguments to recover: have %d, want 0", len(call.Args))
+	}
+	return App{Prim(prim.Recover), id}
+}
+
 func convprim(kind token.Token) Exp {
 	return Prim(primOps[kind])
 }
 
-func convlit(kind token.Token, s string) Exp {
-	switch kind {
+// convval converts a reference to v,
+// the value bound to a name.
+func convval(v Value) Exp {
+	switch v := v.(type) {
+	case ref:
+		return App{Prim(prim.Deref), v.v}
+	case record:
+		return v.v
+	case con:
+		return convconref(v)
+	case function:
+		return v.v
+	}
+	return v
+}
+
+// convlit returns the value of literal x.
+func (cv *converter) convlit(x *ast.BasicLit) Value {
+	switch s := x.Value; x.Kind {
 	case token.INT:
-		v, err := strconv.ParseInt(s, 0, 0)
+		n := parseint(s)
+		if cv.numtype(x, types.Float) {
+			f, _ := new(big.Float).SetInt(n).Float64()
+			return Float(f)
+		}
+		return cv.intlit(x, n)
+	case token.FLOAT:
+		v, err := strconv.ParseFloat(s, 64)
 		if err != nil {
-			panic("bad int literal: " + s)
+			errorf(x.Pos(), "constant %s overflows float", s)
+		}
+		if cv.numtype(x, types.Int) {
+			n, _ := new(big.Float).SetFloat64(v).Int(nil)
+			return cv.intlit(x, n)
+		}
+		return Float(v)
+	case token.CHAR:
+		if cv.numtype(x, types.Float) {
+			return Float(runeval(s))
 		}
-		return Int(v)
+		return runeval(s)
 	case token.STRING:
+		if s[0] == '`' {
+			// as in Go, carriage returns in raw strings are discarded
+			return String(strings.Replace(s[1:len(s)-1], "\r", "", -1))
+		}
 		t, err := strconv.Unquote(s)
 		if err != nil {
 			panic("bad string literal: " + s)
@@ -133,53 +1304,787 @@ func convlit(kind token.Token, s string) Exp {
 	panic("bad lit token")
 }
 
-func convseq(sl []ast.Stmt, r env) Exp {
-	if len(sl) == 0 {
+// numtype returns whether numeric literal x
+// is known to be of type t.
+func (cv *converter) numtype(x *ast.BasicLit, t types.Basic) bool {
+	u, ok := cv.info.Numbers[x]
+	return ok && u == t
+}
+
+// intlit returns n, the value of literal x,
+// as an integer.
+func (cv *converter) intlit(x *ast.BasicLit, n *big.Int) Value {
+	v := intval(n)
+	if _, ok := v.(BigInt); ok && !cv.bigints {
+		errorf(x.Pos(), "constant %s overflows int", x.Value)
+	}
+	return v
+}
+
+// runeval returns the value of rune literal s.
+func runeval(s string) Int {
+	r, _, _, err := strconv.UnquoteChar(s[1:len(s)-1], '\'')
+	if err != nil {
+		panic("bad char literal: " + s)
+	}
+	return Int(r)
+}
+
+func parseint(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		panic("bad int literal: " + s)
+	}
+	return n
+}
+
+// convassign converts an assignment of v
+// to the variable named by id.
+func convassign(id *ast.Ident, v Exp, r env) Exp {
+	c, ok := r(id.Name).(ref)
+	if !ok {
+		errorf(id.Pos(), "cannot assign to %s", id.Name)
+	}
+	return App{Prim(prim.Assign), Record{c.v, v}}
+}
+
+// convvalues converts rhs, the right side of an assignment
+// to n operands, binding each value to a new variable
+// in order, and passes the variables to k.
+// A single call on the right of several operands
+// returns a tuple, whose elements are the values.
+func (cv *converter) convvalues(rhs []ast.Expr, n int, r env, k func(vals []Exp) Exp) Exp {
+	var binds []binding
+	var vals []Exp
+	if len(rhs) == 1 && n > 1 {
+		t := newVar("")
+		var e Exp
+		if x, ok := rhs[0].(*ast.IndexExpr); ok && n == 2 {
+			// v, ok := m[k]
+			e = App{Prim(prim.Lookup), Record{cv.conv(x.X, r), cv.conv(x.Index, r)}}
+		} else {
+			e = cv.conv(rhs[0], r)
+		}
+		binds = append(binds, binding{t, e})
+		for i := 0; i < n; i++ {
+			vals = append(vals, Select{i, t})
+		}
+	} else {
+		for _, x := range rhs {
+			v := newVar("")
+			binds = append(binds, binding{v, cv.conv(x, r)})
+			vals = append(vals, v)
+		}
+	}
+	return let(binds, k(vals))
+}
+
+// A binding is a variable and the value to bind it to.
+type binding struct {
+	v Var
+	e Exp
+}
+
+// let binds each variable in binds,
+// in order, in the scope of body.
+func let(binds []binding, body Exp) Exp {
+	for i := len(binds) - 1; i >= 0; i-- {
+		body = App{Fn{binds[i].v, body}, binds[i].e}
+	}
+	return body
+}
+
+// seq evaluates the expressions in el in order.
+func seq(el []Exp) Exp {
+	if len(el) == 0 {
 		return Int(0)
 	}
-	return App{Fn{newVar(""), convseq(sl[1:], r)}, conv(sl[0], r)}
+	exp := el[len(el)-1]
+	for i := len(el) - 2; i >= 0; i-- {
+		exp = App{Fn{newVar(""), exp}, el[i]}
+	}
+	return exp
+}
+
+func (cv *converter) convseq(sl []ast.Stmt, r env) Exp {
+	return cv.convstmts(sl, r, func(env) Exp {
+		return Int(0)
+	})
+}
+
+// convstmts converts the statements in sl in sequence,
+// followed by the expression made by k.
+// Variables declared in sl are in scope for k.
+func (cv *converter) convstmts(sl []ast.Stmt, r env, k func(env) Exp) Exp {
+	return cv.convblock(sl, r, make(map[string]bool), k)
+}
+
+// convblock is like convstmts,
+// where declared is the set of names
+// already declared in the block.
+func (cv *converter) convblock(sl []ast.Stmt, r env, declared map[string]bool, k func(env) Exp) Exp {
+	if len(sl) == 0 {
+		return k(r)
+	}
+	s, ok := sl[0].(*ast.AssignStmt)
+	if !ok || s.Tok != token.DEFINE {
+		return App{Fn{newVar(""), cv.convblock(sl[1:], r, declared, k)}, cv.conv(sl[0], r)}
+	}
+	if len(s.Lhs) == 1 && len(s.Rhs) == 1 {
+		id, ok := s.Lhs[0].(*ast.Ident)
+		if !ok {
+			errorf(s.Pos(), "non-name on left side of :=")
+		}
+		declared[id.Name] = true
+		if x, ok := s.Rhs[0].(*ast.RecordLit); ok && !assigned(&ast.BlockStmt{List: sl[1:]})[id.Name] {
+			// Its fields are known, so selections
+			// can use offsets instead of names.
+			v := newVar(id.Name)
+			rest := cv.convblock(sl[1:], bind(r, id.Name, record{v, fieldNames(x)}), declared, k)
+			return App{Fn{v, rest}, cv.conv(x, r)}
+		}
+		cell := App{Prim(prim.Makeref), cv.conv(s.Rhs[0], r)}
+		v := newVar(id.Name)
+		return App{Fn{v, cv.convblock(sl[1:], bind(r, id.Name, ref{v}), declared, k)}, cell}
+	}
+	// Names already declared in the block are assigned;
+	// the others are new variables.
+	return cv.convvalues(s.Rhs, len(s.Lhs), r, func(vals []Exp) Exp {
+		var binds []binding
+		var el []Exp
+		r1 := r
+		for i, x := range s.Lhs {
+			id, ok := x.(*ast.Ident)
+			if !ok {
+				errorf(x.Pos(), "non-name on left side of :=")
+			}
+			if id.Name == "_" {
+				continue
+			}
+			if c, ok := r(id.Name).(ref); ok && declared[id.Name] {
+				el = append(el, App{Prim(prim.Assign), Record{c.v, vals[i]}})
+				continue
+			}
+			declared[id.Name] = true
+			v := newVar(id.Name)
+			binds = append(binds, binding{v, App{Prim(prim.Makeref), vals[i]}})
+			r1 = bind(r1, id.Name, ref{v})
+		}
+		rest := cv.convblock(sl[1:], r1, declared, k)
+		return let(binds, seq(append(el, rest)))
+	})
 }
 
-func convl(xl []ast.Expr, r env) (el []Exp) {
+func (cv *converter) convl(xl []ast.Expr, r env) (el []Exp) {
 	for _, x := range xl {
-		el = append(el, conv(x, r))
+		el = append(el, cv.conv(x, r))
 	}
 	return el
 }
 
-func convfunc(params []*ast.Ident, body ast.Node, r env) Fn {
+// convfunc converts a function with the given params and body.
+// A parameter that is assigned to in body
+// is copied into a cell, like a variable made with :=.
+// Id is the function's id; see Convert.
+func (cv *converter) convfunc(params []*ast.Ident, body ast.Node, id Int, r env) Fn {
+	r = bind(r, "func", id)
 	v := newVar("")
-	var pl []Var
+	mut := assigned(body)
+	var pl, cl []Var
 	for _, s := range params {
-		var p Var
-		r, p = bindvar(r, s)
+		p := newVar(s.Name)
+		c := p
+		if mut[s.Name] {
+			c = newVar(s.Name)
+			r = bind(r, s.Name, ref{c})
+		} else {
+			r = bind(r, s.Name, p)
+		}
 		pl = append(pl, p)
+		cl = append(cl, c)
 	}
-	exp := convfuncbody(body, r)
+	exp := cv.convfuncbody(body, params, r)
 	for i, p := range pl {
+		if c := cl[i]; c != p {
+			exp = App{Fn{c, exp}, App{Prim(prim.Makeref), p}}
+		}
 		exp = App{Fn{p, exp}, Select{i, v}}
 	}
 	return Fn{v, exp}
 }
 
-// save continuation as "return", evaluate body
-func convfuncbody(body ast.Node, r env) Exp {
+// assigned returns the set of names assigned with =, ++ or --,
+// or that might be assigned with :=,
+// anywhere in node, including in nested functions.
+func assigned(node ast.Node) map[string]bool {
+	m := make(map[string]bool)
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch s := n.(type) {
+		case *ast.AssignStmt:
+			if s.Tok == token.DEFINE && len(s.Lhs) == 1 {
+				break // declares a new variable
+			}
+			// A := with several names might assign
+			// any that are already declared.
+			for _, x := range s.Lhs {
+				if id, ok := x.(*ast.Ident); ok {
+					m[id.Name] = true
+				}
+			}
+		case *ast.IncDecStmt:
+			if id, ok := s.X.(*ast.Ident); ok {
+				m[id.Name] = true
+			}
+		case *ast.ForStmt:
+			if s.Tok != token.ASSIGN {
+				break
+			}
+			for _, x := range []ast.Expr{s.Key, s.Value} {
+				if id, ok := x.(*ast.Ident); ok {
+					m[id.Name] = true
+				}
+			}
+		}
+		return true
+	})
+	return m
+}
+
+// convfuncbody saves the continuation as "return"
+// and evaluates body, whose top level
+// also declares the params.
+//
+// Panics are built on continuations and the
+// primitives of package prim. If body has
+// deferred calls, it keeps them in a cell bound
+// to "defer", as a chain of functions each of
+// which makes the call and then those deferred
+// before it (see convdefer). Around body, with
+// "return" captured, it sets a panic handler
+// that makes the deferred calls, restores the
+// previous handler, and continues the panic if
+// it was not recovered, or else returns 0
+// through "return".
+func (cv *converter) convfuncbody(body ast.Node, params []*ast.Ident, r env) Exp {
+	convbody := func(r env) Exp {
+		b, ok := body.(*ast.BlockStmt)
+		if !ok {
+			return cv.conv(body, r)
+		}
+		declared := make(map[string]bool)
+		for _, p := range params {
+			declared[p.Name] = true
+		}
+		return cv.convblock(b.List, r, declared, func(env) Exp {
+			return Int(0)
+		})
+	}
+	if !defers(body) {
+		return escape([]string{"return"}, r, convbody)
+	}
+
+	ds, prev, prevd, v := newVar("defer"), newVar(""), newVar(""), newVar("")
+	rundefers := App{App{Prim(prim.Deref), ds}, Record{}}
+	restore := App{Prim(prim.SetHandler), prev}
+	exp := escape([]string{"return"}, bind(r, "defer", ds), func(r env) Exp {
+		// A deferred call that panicked did
+		// not put back the id it noted.
+		h := Fn{newVar(""), seq([]Exp{
+			App{Prim(prim.SetDeferID), prevd},
+			rundefers,
+			restore,
+			App{Prim(prim.Repanic), Record{}},
+			App{r("return"), Record{Int(0)}},
+		})}
+		return seq([]Exp{App{Prim(prim.SetHandler), h}, convbody(r)})
+	})
+	return let([]binding{
+		{ds, App{Prim(prim.Makeref), Fn{newVar(""), Int(0)}}},
+		{prev, App{Prim(prim.Handler), Record{}}},
+		{prevd, App{Prim(prim.DeferID), Record{}}},
+		{v, exp},
+	}, seq([]Exp{rundefers, restore, v}))
+}
+
+// defers returns whether body has a defer statement,
+// other than in a nested function.
+func defers(body ast.Node) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.FuncLit, *ast.ShortFuncLit:
+			return false
+		case *ast.DeferStmt:
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// convdefer converts s, which adds a function to
+// the chain of deferred calls in the cell bound to
+// "defer". The function and arguments of the call
+// are evaluated now; the function makes the call,
+// after putting back the chain as it was and
+// noting the id of the function called, and
+// then calls the rest of the chain.
+// Each function has an id, bound to "func" in
+// its body, and noting it means only a call of
+// recover directly in that function stops a panic.
+func (cv *converter) convdefer(s *ast.DeferStmt, r env) Exp {
+	call := s.Call
+	var binds []binding
+	var args []Exp
+	id := cv.deferid(call.Fun, r)
+	f := cv.conv(call.Fun, r)
+	p, isPrim := f.(Prim)
+	if !isPrim {
+		fv := newVar("")
+		binds = append(binds, binding{fv, f})
+		f = fv
+	}
+	for _, x := range call.Args {
+		v := newVar("")
+		binds = append(binds, binding{v, cv.conv(x, r)})
+		args = append(args, v)
+	}
+	var exp Exp = App{f, Record(args)}
+	switch {
+	case isPrim && prim.Op(p) == prim.Recover:
+		// not called by a deferred function
+		exp = convrecover(call, 0)
+	case isPrim:
+		exp = convprimcall(call, prim.Op(p), args)
+	}
+	ds, rest, prevd := r("defer"), newVar(""), newVar("")
+	fn := Fn{newVar(""), let([]binding{{prevd, App{Prim(prim.DeferID), Record{}}}}, seq([]Exp{
+		App{Prim(prim.Assign), Record{ds, rest}},
+		App{Prim(prim.SetDeferID), id},
+		exp,
+		App{Prim(prim.SetDeferID), prevd},
+		App{rest, Record{}},
+	}))}
+	binds = append(binds, binding{rest, App{Prim(prim.Deref), ds}})
+	return let(binds, App{Prim(prim.Assign), Record{ds, fn}})
+}
+
+// deferid returns the id of the function that x,
+// the function of a deferred call, refers to:
+// a function literal, a function declared at the
+// top level, or a method. It returns 0 otherwise.
+func (cv *converter) deferid(x ast.Expr, r env) Int {
+	switch x := x.(type) {
+	case *ast.FuncLit, *ast.ShortFuncLit:
+		return cv.litid(x)
+	case *ast.SelectorExpr:
+		if m := cv.info.Methods[x]; m != nil {
+			return funcid(cv.methods[m.Decl].direct)
+		}
+	}
+	if fv, ok := lookupfunc(x, r); ok {
+		return funcid(fv.direct)
+	}
+	return 0
+}
+
+// funcid returns the id of the function declared
+// at the top level, or method, whose direct Var is v.
+// The ids of Vars are unique across packages.
+func funcid(v Var) Int {
+	return Int(v.ID)
+}
+
+// litid returns the id of function literal x.
+func (cv *converter) litid(x ast.Node) Int {
+	id, ok := cv.litids[x]
+	if !ok {
+		id = funcid(newVar(""))
+		cv.litids[x] = id
+	}
+	return id
+}
+
+// frame returns f, the function named name,
+// with its name on the frames in the trace of
+// a panic while its body runs.
+func frame(name string, f Fn) Fn {
+	n, v := newVar(""), newVar("")
+	binds := []binding{
+		{n, App{Prim(prim.Enter), String(name)}},
+		{v, f.Body},
+	}
+	return Fn{f.V, let(binds, seq([]Exp{App{Prim(prim.Leave), n}, v}))}
+}
+
+// escape captures the current continuation with callcc,
+// binds it to each of names in r,
+// and converts the expression made by f in that environment.
+func escape(names []string, r env, f func(env) Exp) Exp {
 	rec := newVar("")
-	ret := newVar("")
-	r = bind(r, "return", ret)
+	k := newVar(names[0])
+	for _, name := range names {
+		r = bind(r, name, k)
+	}
 	return App{Prim(prim.Callcc), Record{Fn{rec,
-		App{Fn{ret, conv(body, r)}, Select{0, rec}},
+		App{Fn{k, f(r)}, Select{0, rec}},
 	}}}
 }
 
+// convfor converts a for loop.
+// If label is not nil, it is the loop's label.
+//
+// The loop is a recursive function that tests the condition
+// and, if it holds, runs the body and post statement once
+// and calls itself again. If the body has break or continue
+// statements, they are escape continuations captured
+// with callcc around the loop and around the body,
+// in the same way as return.
+func (cv *converter) convfor(s *ast.ForStmt, label *ast.Ident, r env) Exp {
+	brk, cont := branches(s.Body, label)
+	body := func(r env) Exp {
+		if len(cont) > 0 {
+			return escape(cont, r, func(r env) Exp {
+				return cv.conv(s.Body, r)
+			})
+		}
+		return cv.conv(s.Body, r)
+	}
+	loop := func(r env) Exp {
+		if s.X != nil {
+			return cv.convrange(s, body, r)
+		}
+		v := newVar("loop")
+		var next Exp = App{v, Int(0)}
+		if s.Post != nil {
+			next = App{Fn{newVar(""), next}, cv.conv(s.Post, r)}
+		}
+		var iter Exp = App{Fn{newVar(""), next}, body(r)}
+		if s.Cond != nil {
+			iter = Switch{
+				Value:   cv.conv(s.Cond, r),
+				Cases:   []Case{{IntCon(0), Int(0)}},
+				Default: iter,
+			}
+		}
+		return Fix{
+			Names: []Var{v},
+			Fns:   []Fn{{newVar(""), iter}},
+			Body:  App{v, Int(0)},
+		}
+	}
+	k := loop
+	if len(brk) > 0 {
+		k = func(r env) Exp {
+			return escape(brk, r, loop)
+		}
+	}
+	if s.Init != nil {
+		return cv.convstmts([]ast.Stmt{s.Init}, r, k)
+	}
+	return k(r)
+}
+
+// convrange converts range loop s, whose body,
+// given the environment of an iteration, is made by body.
+//
+// The pairs of keys and values to go through are
+// listed by prim.Range before the loop starts,
+// and the loop function takes the index of the next.
+// With :=, each iteration has its own variables,
+// as in Go. With =, the operands of index expressions
+// are evaluated before the key or value is assigned,
+// as in an assignment.
+func (cv *converter) convrange(s *ast.ForStmt, body func(env) Exp, r env) Exp {
+	pairs, n := newVar(""), newVar("")
+	loop, i, pair := newVar("loop"), newVar(""), newVar("")
+	var binds []binding
+	var sets []Exp
+	r1 := r
+	for j, x := range []ast.Expr{s.Key, s.Value} {
+		if x == nil {
+			continue
+		}
+		val := Select{j, pair}
+		switch x := x.(type) {
+		case *ast.Ident:
+			switch {
+			case x.Name == "_":
+			case s.Tok == token.DEFINE:
+				v := newVar(x.Name)
+				binds = append(binds, binding{v, App{Prim(prim.Makeref), val}})
+				r1 = bind(r1, x.Name, ref{v})
+			default:
+				sets = append(sets, convassign(x, val, r))
+			}
+		case *ast.IndexExpr:
+			a, k := newVar(""), newVar("")
+			binds = append(binds, binding{a, cv.conv(x.X, r)}, binding{k, cv.conv(x.Index, r)})
+			sets = append(sets, App{Prim(prim.SetIndex), Record{a, k, val}})
+		default:
+			errorf(x.Pos(), "cannot assign to expression")
+		}
+	}
+	next := App{loop, App{Prim(prim.Add), Record{i, Int(1)}}}
+	iter := let(append([]binding{{pair, App{Prim(prim.Index), Record{pairs, i}}}}, binds...),
+		seq(append(sets, body(r1), next)))
+	return let([]binding{
+		{pairs, App{Prim(prim.Range), cv.conv(s.X, r)}},
+		{n, App{Prim(prim.Len), pairs}},
+	}, Fix{
+		Names: []Var{loop},
+		Fns: []Fn{{i, Switch{
+			Value:   App{Prim(prim.Lt), Record{i, n}},
+			Cases:   []Case{{IntCon(0), Int(0)}},
+			Default: iter,
+		}}},
+		Body: App{loop, Int(0)},
+	})
+}
+
+// convswitch converts a switch statement.
+// If label is not nil, it is the switch's label.
+//
+// The tag is bound to a variable,
+// and each pattern in a case becomes a Case of a Switch.
+// An integer pattern outside the range of Int, which
+// needs BigInts, is instead compared with == when
+// no Case matches.
+// For a constructor, the body binds the pattern's names
+// to the fields.
+// A case with more than one pattern binds no names,
+// so its body is converted once, as a function that
+// the Case of each pattern calls.
+// If the cases have break statements,
+// they are escape continuations as in convfor.
+func (cv *converter) convswitch(s *ast.SwitchStmt, label *ast.Ident, r env) Exp {
+	brk, _ := branches(s, label)
+	sw := func(r env) Exp {
+		x := newVar("")
+		exp := Switch{Value: x, Default: Int(0)}
+		var bigs, bigBodies []Exp
+		var joins []binding
+		for _, cc := range s.Cases {
+			if cc.List == nil {
+				exp.Default = cv.convseq(cc.Body, r)
+				continue
+			}
+			mut := assigned(&ast.BlockStmt{List: cc.Body})
+			convbody := func(r env) Exp {
+				return cv.convseq(cc.Body, r)
+			}
+			if len(cc.List) > 1 {
+				j := newVar("case")
+				joins = append(joins, binding{j, Fn{newVar(""), cv.convseq(cc.Body, r)}})
+				convbody = func(env) Exp {
+					return App{j, Int(0)}
+				}
+			}
+			for _, pat := range cc.List {
+				if v, ok := IntPattern(pat); ok {
+					exp.Cases = append(exp.Cases, Case{IntCon(v), convbody(r)})
+					continue
+				}
+				if n, ok := BigPattern(pat); ok {
+					if !cv.bigints {
+						errorf(pat.Pos(), "constant %s overflows int", n)
+					}
+					bigs = append(bigs, BigInt{n})
+					bigBodies = append(bigBodies, convbody(r))
+					continue
+				}
+				var params []ast.Expr
+				if call, ok := pat.(*ast.CallExpr); ok {
+					pat, params = call.Fun, call.Args
+				}
+				c, ok := lookupcon(pat, r)
+				if !ok {
+					errorf(pat.Pos(), "invalid pattern")
+				}
+				exp.Poss = c.poss
+				r1 := r
+				var binds []func(Exp) Exp
+				for i, p := range params {
+					id := p.(*ast.Ident)
+					if id.Name == "_" {
+						continue
+					}
+					v := newVar(id.Name)
+					field := Select{i + fieldOffset(c.rep), x}
+					if mut[id.Name] {
+						cell := newVar(id.Name)
+						r1 = bind(r1, id.Name, ref{cell})
+						binds = append(binds, func(e Exp) Exp {
+							return App{Fn{v, App{Fn{cell, e}, App{Prim(prim.Makeref), v}}}, field}
+						})
+					} else {
+						r1 = bind(r1, id.Name, v)
+						binds = append(binds, func(e Exp) Exp {
+							return App{Fn{v, e}, field}
+						})
+					}
+				}
+				body := convbody(r1)
+				for i := len(binds) - 1; i >= 0; i-- {
+					body = binds[i](body)
+				}
+				exp.Cases = append(exp.Cases, Case{DataCon{c.name, c.rep}, body})
+			}
+		}
+		for i := len(bigs) - 1; i >= 0; i-- {
+			exp.Default = Switch{
+				Value:   App{convprim(token.EQL), Record{x, bigs[i]}},
+				Cases:   []Case{{IntCon(0), exp.Default}},
+				Default: bigBodies[i],
+			}
+		}
+		if len(exp.Cases) == 0 {
+			return App{Fn{x, let(joins, exp.Default)}, cv.conv(s.Tag, r)}
+		}
+		return App{Fn{x, let(joins, exp)}, cv.conv(s.Tag, r)}
+	}
+	if len(brk) > 0 {
+		return escape(brk, r, sw)
+	}
+	return sw(r)
+}
+
+// branches returns the names that the break and continue
+// statements in body use to refer to the loop or switch
+// whose body it is, in the form bound by convfor.
+// A label in body belongs to the loop or switch
+// if it is equal to label, which may be nil.
+func branches(body ast.Node, label *ast.Ident) (brk, cont []string) {
+	var found [2]bool // unlabeled break, continue
+	var foundLabel [2]bool
+	var visit func(n ast.Node, nested [2]bool)
+	visit = func(n ast.Node, nested [2]bool) {
+		ast.Inspect(n, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncLit, *ast.ShortFuncLit:
+				return false
+			case *ast.ForStmt:
+				visit(n.Body, [2]bool{true, true})
+				return false
+			case *ast.SwitchStmt:
+				if n != body {
+					for _, cc := range n.Cases {
+						visit(cc, [2]bool{true, nested[1]})
+					}
+					return false
+				}
+			case *ast.BranchStmt:
+				i := 0
+				if n.Tok == token.CONTINUE {
+					i = 1
+				}
+				switch {
+				case n.Label == nil && !nested[i]:
+					found[i] = true
+				case n.Label != nil && label != nil && n.Label.Name == label.Name:
+					foundLabel[i] = true
+				}
+			}
+			return true
+		})
+	}
+	visit(body, [2]bool{})
+	names := func(tok token.Token, i int) (a []string) {
+		if found[i] || foundLabel[i] {
+			a = append(a, tok.String())
+			if label != nil {
+				a = append(a, tok.String()+" "+label.Name)
+			}
+		}
+		return a
+	}
+	return names(token.BREAK, 0), names(token.CONTINUE, 1)
+}
+
 var globalEnv env
 
+// IntPattern returns the value of x,
+// if x is an integer constant that can be
+// a case in a switch: an integer or rune literal,
+// possibly negated, in the range of Int.
+func IntPattern(x ast.Expr) (v int, ok bool) {
+	n, ok := BigPattern(x)
+	if !ok || !n.IsInt64() || n.Int64() > MaxInt || n.Int64() < -MaxInt {
+		return 0, false
+	}
+	return int(n.Int64()), true
+}
+
+// BigPattern returns the value of x, if x is an
+// integer or rune literal, possibly negated.
+// Outside the range of Int, such a pattern is
+// only valid with BigInts.
+func BigPattern(x ast.Expr) (n *big.Int, ok bool) {
+	neg := false
+	if u, isUnary := x.(*ast.UnaryExpr); isUnary && u.Op == token.SUB {
+		x, neg = u.X, true
+	}
+	lit, ok := x.(*ast.BasicLit)
+	if !ok || lit.Kind != token.INT && lit.Kind != token.CHAR {
+		return nil, false
+	}
+	if lit.Kind == token.CHAR {
+		n = big.NewInt(int64(runeval(lit.Value)))
+	} else {
+		n = parseint(lit.Value)
+	}
+	if neg {
+		n.Neg(n)
+	}
+	return n, true
+}
+
+// Builtin returns whether name is predeclared
+// in every package.
+func Builtin(name string) bool {
+	return globalEnv(name) != nil
+}
+
+// BuiltinType returns whether name is a predeclared
+// type, and so may be used in an annotation.
+// Each is also a conversion function.
+func BuiltinType(name string) bool {
+	return name == "int" || name == "float" || name == "string"
+}
+
+// BuiltinConst returns whether name is
+// a predeclared constant.
+func BuiltinConst(name string) bool {
+	return isconst(globalEnv(name))
+}
+
+// BuiltinFunc returns whether name is
+// a predeclared function.
+func BuiltinFunc(name string) bool {
+	_, ok := globalEnv(name).(Prim)
+	return ok
+}
+
 func init() {
 	r := env0
 	r = bind(r, "false", Int(0))
 	r = bind(r, "true", Int(1))
+	r = bind(r, "nil", Nil{})
 	r = bind(r, "println", Prim(prim.Println))
 	r = bind(r, "callcc", Prim(prim.Callcc))
+	r = bind(r, "panic", Prim(prim.Panic))
+	r = bind(r, "recover", Prim(prim.Recover))
+	r = bind(r, "len", Prim(prim.Len))
+	r = bind(r, "append", Prim(prim.Append))
+	r = bind(r, "delete", Prim(prim.Delete))
+	r = bind(r, "keys", Prim(prim.Keys))
+	r = bind(r, "int", Prim(prim.ToInt))
+	r = bind(r, "float", Prim(prim.ToFloat))
+	r = bind(r, "string", Prim(prim.RuneStr))
+	r = bind(r, "itoa", Prim(prim.Itoa))
+	r = bind(r, "atoi", Prim(prim.Atoi))
+	r = bind(r, "runes", Prim(prim.Runes))
+	r = bind(r, "decoderune", Prim(prim.DecodeRune))
 	globalEnv = r
 }
 
@@ -187,8 +2092,10 @@ func init() {
 // it keeps track of lexical scope
 type env func(name string) Value
 
+// env0 is the empty environment.
+// It returns nil for every name.
 func env0(name string) Value {
-	panic("undefined: " + name)
+	return nil
 }
 
 func bind(r env, name string, v Value) env {
@@ -200,14 +2107,9 @@ func bind(r env, name string, v Value) env {
 	}
 }
 
-// bindvar augments r with a newly introduced Var bound to name.
-func bindvar(r env, name *ast.Ident) (env, Var) {
-	v := newVar(name.Name)
-	return bind(r, name.Name, v), v
-}
-
 // bindimports augments r with a binding
-// for each package listed in a.
+// for each package listed in a, or for a dot import,
+// for each name the package exports.
 func bindimports(r env, a []*ast.ImportSpec, pkgtab func(string) Tab) env {
 	for _, spec := range a {
 		dep := pkgtab(spec.Path.String())
@@ -215,7 +2117,15 @@ func bindimports(r env, a []*ast.ImportSpec, pkgtab func(string) Tab) env {
 		if spec.Name != nil {
 			name = spec.Name.Name
 		}
-		r = bind(r, name, pkg{dep})
+		switch name {
+		case "_":
+		case ".":
+			for n, v := range dep.sym {
+				r = bind(r, n, v)
+			}
+		default:
+			r = bind(r, name, pkg{dep})
+		}
 	}
 	return r
 }
@@ -5,6 +5,7 @@ package fun
 
 import (
 	"go/token"
+	"math/big"
 
 	"github.com/kr/bubble/prim"
 )
@@ -13,28 +14,42 @@ type Exp interface {
 	exp()
 }
 
-func (App) exp()    {}
-func (Fix) exp()    {}
-func (Fn) exp()     {}
-func (Int) exp()    {}
-func (Prim) exp()   {}
-func (Record) exp() {}
-func (Select) exp() {}
-func (String) exp() {}
-func (Switch) exp() {}
-func (Var) exp()    {}
-func (pkg) exp()    {}
+func (App) exp()      {}
+func (con) exp()      {}
+func (function) exp() {}
+func (BigInt) exp()   {}
+func (Fix) exp()      {}
+func (Float) exp()    {}
+func (Fn) exp()       {}
+func (Int) exp()      {}
+func (Nil) exp()      {}
+func (Prim) exp()     {}
+func (Record) exp()   {}
+func (Select) exp()   {}
+func (String) exp()   {}
+func (Switch) exp()   {}
+func (Var) exp()      {}
+func (pkg) exp()      {}
+func (record) exp()   {}
+func (ref) exp()      {}
 
 type Value interface {
 	Exp
 	value()
 }
 
-func (Int) value()    {}
-func (Prim) value()   {}
-func (String) value() {}
-func (Var) value()    {}
-func (pkg) value()    {}
+func (BigInt) value()   {}
+func (con) value()      {}
+func (Float) value()    {}
+func (function) value() {}
+func (Int) value()      {}
+func (Nil) value()      {}
+func (Prim) value()     {}
+func (String) value()   {}
+func (Var) value()      {}
+func (pkg) value()      {}
+func (record) value()   {}
+func (ref) value()      {}
 
 type Var struct {
 	ID   uint
@@ -53,10 +68,55 @@ type pkg struct {
 	tab Tab
 }
 
+// A ref is a mutable variable.
+// Its Var is bound to a cell made by prim.Makeref.
+type ref struct {
+	v Var
+}
+
+// A record is a variable bound to a record literal
+// and never assigned, so its fields are known.
+type record struct {
+	v      Var
+	fields []string
+}
+
+// A con is a constructor of a data type.
+type con struct {
+	name  string
+	typ   string // name of the data type
+	rep   Conrep
+	poss  []Conrep // representations of all the type's constructors
+	arity int
+	fn    Var // function that makes a value; unused if arity is 0
+}
+
+// A function is a function declared at the top level.
+// Its number of parameters is known,
+// so direct calls can skip the check
+// that the number of arguments matches.
+type function struct {
+	v        Var // checks its arguments
+	direct   Var // does not check; for variadic, takes a list of the rest
+	nparam   int
+	variadic bool
+}
+
 type Int int
 
+type Float float64
+
+// A BigInt is an integer too large to be an Int.
+type BigInt struct {
+	*big.Int
+}
+
 type String string
 
+// Nil is the value of nil,
+// which recover yields when there is no panic.
+type Nil struct{}
+
 type Fn struct {
 	V    Var
 	Body Exp
@@ -92,6 +152,13 @@ var primOps = [...]prim.Op{
 	token.SUB: prim.Sub,
 	token.MUL: prim.Mul,
 	token.QUO: prim.Quo,
+	token.REM: prim.Rem,
+	token.EQL: prim.Eql,
+	token.NEQ: prim.Ineq,
+	token.LSS: prim.Lt,
+	token.LEQ: prim.Leq,
+	token.GTR: prim.Gt,
+	token.GEQ: prim.Geq,
 }
 
 type Record []Exp
@@ -115,6 +182,18 @@ type Selp struct {
 	P Path
 }
 
+// A Conrep is the representation of the values
+// made by a constructor, chosen from the
+// constructors of its data type as in Appel's
+// Compiling with Continuations.
+// A constructor without fields is a Constant.
+// If its type has only one constructor with
+// fields, that one is Untagged; otherwise
+// each with fields is Tagged.
+// The type checker ensures that a value
+// switched on has the data type of the
+// patterns, so the representations of one
+// type need not be told from those of another.
 type Conrep interface {
 	conrep()
 }
@@ -127,16 +206,30 @@ type ConrepUnit int
 
 const (
 	Undecided ConrepUnit = iota
+	Untagged             // a record of the fields alone
 )
 
+// A Tagged value is a record of its tag
+// followed by its fields.
 type Tagged int
 
+// A Constant value is the integer itself.
+// A value with fields is a record, so the
+// two are told apart by prim.Boxed.
 type Constant int
 
 type Con interface {
 	con()
 }
 
-func (IntCon) con() {}
+func (DataCon) con() {}
+func (IntCon) con()  {}
+
+// A DataCon is a data type constructor
+// with representation Rep.
+type DataCon struct {
+	Name string
+	Rep  Conrep
+}
 
 type IntCon int
@@ -14,12 +14,14 @@ import (
 	"os/exec"
 
 	"github.com/kr/bubble/build"
+	"github.com/kr/bubble/parser"
 )
 
 var (
 	flagD = flag.Bool("d", false, "debug")
 	flagO = flag.String("o", "", "output file")
 	flagR = flag.Bool("r", true, "run program")
+	flagB = flag.Bool("bigint", false, "make integers unbounded")
 )
 
 func init() {
@@ -31,6 +33,9 @@ func main() {
 	if *flagD {
 		build.Mode |= build.Debug
 	}
+	if *flagB {
+		build.Mode |= build.BigInts
+	}
 
 	if s := os.Getenv("BUBBLEROOT"); s != "" {
 		build.BUBBLEROOT = s
@@ -59,7 +64,8 @@ func main() {
 
 	err = build.Build(targ, flag.Args()...)
 	if err != nil {
-		log.Fatalln(err)
+		parser.PrintError(os.Stderr, err)
+		os.Exit(1)
 	}
 
 	if *flagO == "" && *flagR {
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/kr/bubble/build"
+	"github.com/kr/bubble/parser"
 )
 
 func TestCompile(t *testing.T) {
@@ -22,20 +23,54 @@ func TestCompile(t *testing.T) {
 	}
 }
 
+// TestBigInts checks that the samples with output
+// print the same with unbounded integers,
+// and runs the samples in sample/bigint,
+// which need them.
+func TestBigInts(t *testing.T) {
+	build.Mode |= build.BigInts
+	defer func() { build.Mode &^= build.BigInts }()
+	files, err := filepath.Glob("sample/*.b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Error(file, err)
+			continue
+		}
+		if _, ok := magicComment(src, "Output"); ok {
+			testonefile(t, file)
+		}
+	}
+	files, err = filepath.Glob("sample/bigint/*.b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		testonefile(t, file)
+	}
+}
+
 func testonefile(t *testing.T, name string) {
 	src, err := ioutil.ReadFile(name)
 	if err != nil {
 		t.Error(name, err)
 		return
 	}
-	const magic = "\n// Output:"
-	p := bytes.Index(src, []byte(magic))
-	if p < 0 {
+	if want, ok := magicComment(src, "Error"); ok {
+		testerror(t, name, want)
+		return
+	}
+	if want, ok := magicComment(src, "Panic"); ok {
+		testpanic(t, name, want)
+		return
+	}
+	want, ok := magicComment(src, "Output")
+	if !ok {
 		return
 	}
-	want := strings.TrimSpace(
-		strings.Replace(string(src[p+len(magic):]), "\n// ", "\n", -1),
-	)
 
 	tmpf, err := ioutil.TempFile("", "bubbletest")
 	if err != nil {
@@ -67,3 +102,62 @@ func testonefile(t *testing.T, name string) {
 		t.Errorf("%s got %q want %q", name, got, want)
 	}
 }
+
+// testpanic checks that file name builds ok
+// and then fails at run time with the panic want.
+func testpanic(t *testing.T, name, want string) {
+	tmpf, err := ioutil.TempFile("", "bubbletest")
+	if err != nil {
+		t.Error(name, err)
+		return
+	}
+	err = build.Build(tmpf, name)
+	if err != nil {
+		t.Error(name, err)
+		return
+	}
+	tmpf.Seek(0, 0)
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("node")
+	cmd.Stdin = tmpf
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err == nil {
+		t.Errorf("%s ran ok, want panic", name)
+		return
+	}
+	if !strings.Contains(stderr.String(), "panic: "+want) {
+		t.Errorf("%s got %q want panic %q", name, stderr.String(), want)
+	}
+}
+
+// testerror checks that building file name fails,
+// printing the errors in want.
+// File names are removed from the error positions.
+func testerror(t *testing.T, name, want string) {
+	err := build.Build(ioutil.Discard, name)
+	if err == nil {
+		t.Errorf("%s built ok, want error", name)
+		return
+	}
+	var b bytes.Buffer
+	parser.PrintError(&b, err)
+	got := strings.TrimSpace(strings.Replace(b.String(), name+":", "", -1))
+	if got != want {
+		t.Errorf("%s got error %q want %q", name, got, want)
+	}
+}
+
+// magicComment returns the text of the comment
+// of the form "// <kind>:" at the end of src,
+// with the leading "// " removed from each line.
+func magicComment(src []byte, kind string) (string, bool) {
+	magic := "\n// " + kind + ":"
+	p := bytes.Index(src, []byte(magic))
+	if p < 0 {
+		return "", false
+	}
+	return strings.TrimSpace(
+		strings.NewReplacer("\n// ", "\n", "\n//", "\n").Replace(string(src[p+len(magic):])),
+	), true
+}
@@ -3,6 +3,7 @@ package naivegen
 import (
 	"fmt"
 	"log"
+	"math"
 	"strconv"
 	"strings"
 
@@ -15,17 +16,478 @@ function drive(f0) {
 	F.push(f0);
 	while (F.length > 0) {
 		var f = F.shift();
-		var c = f[0].apply(null, f.slice(1));
+		var c;
+		try {
+			c = f[0].apply(null, f.slice(1));
+		} catch (e) {
+			if (!(e instanceof Panic)) {
+				throw e;
+			}
+			panicking = e;
+			if (handler === null) {
+				die(e);
+				return;
+			}
+			c = [handler, [e.v], halt];
+		}
 		if (c.length > 0) {
 			F.push(c);
 		}
 	}
 }
+function halt() {
+	return [];
+}
+// The names of the Bubble functions running,
+// the function to call on a panic, the panic
+// in progress, if any, and the id of the
+// function called by the running deferred call.
+var frames = [];
+var handler = null;
+var panicking = null;
+var deferring = 0;
+function Panic(v) {
+	this.v = v === undefined ? "panic called with nil argument" : v;
+	this.frames = frames.slice();
+}
+function recover(id) {
+	if (!panicking || id === 0 || id !== deferring) {
+		return undefined;
+	}
+	var v = panicking.v;
+	panicking = null;
+	return v;
+}
+function die(p) {
+	console.error("panic:", show(p.v));
+	console.error("");
+	for (var i = p.frames.length - 1; i >= 0; i--) {
+		console.error(utf8(p.frames[i]));
+	}
+	if (typeof process !== "undefined") {
+		process.exitCode = 2;
+	}
+}
+function Float(v) {
+	this.v = v;
+}
+Float.prototype.valueOf = function() {
+	return this.v;
+};
+Float.prototype.toString = function() {
+	var s = String(this.v);
+	return /^-?[0-9]+$/.test(s) ? s + ".0" : s;
+};
+Float.prototype[Symbol.for("nodejs.util.inspect.custom")] = Float.prototype.toString;
+// Records and values of data types with fields
+// are arrays, equal if their elements are.
+function eql(a, b) {
+	if (a instanceof Float || b instanceof Float) {
+		return Number(a) === Number(b);
+	}
+	if (a instanceof Array && b instanceof Array) {
+		if (a.length !== b.length) {
+			return false;
+		}
+		for (var i = 0; i < a.length; i++) {
+			if (!eql(a[i], b[i])) {
+				return false;
+			}
+		}
+		return true;
+	}
+	return a === b;
+}
+function fail(s) {
+	throw new Panic("runtime error: " + s);
+}
+function index(a, i) {
+	if (a instanceof Map) {
+		var e = lookup(a, i);
+		if (!e[1]) {
+			fail("key not found in map");
+		}
+		return e[0];
+	}
+	if (!(i >= 0 && i < a.length)) {
+		fail("index out of range [" + i + "] with length " + a.length);
+	}
+	return typeof a === "string" ? a.charCodeAt(i) : a[i];
+}
+function indexzero(a, i) {
+	return a instanceof Map ? lookup(a, i)[0] : index(a, i);
+}
+function setindex(a, i, x) {
+	if (typeof a === "string") {
+		fail("cannot assign to string index");
+	}
+	if (a instanceof Map) {
+		a.set(mapkey(i), [i, x]);
+		return;
+	}
+	index(a, i);
+	a[i] = x;
+}
+function len(a) {
+	return a instanceof Map ? a.size : a.length;
+}
+// A map holds [key, value] pairs, indexed by
+// mapkey(key) so that keys compare as by eql.
+// An array is encoded as a string of its elements'
+// keys, starting with a character no Bubble string,
+// whose characters are bytes, can have.
+function mapkey(k) {
+	if (k instanceof Float) {
+		return Number(k);
+	}
+	if (k instanceof Array) {
+		return "\u0100" + k.map(function(x) {
+			var y = mapkey(x);
+			return typeof y === "string" ? JSON.stringify(y) : typeof y === "bigint" ? y + "n" : String(y);
+		}).join(",");
+	}
+	return k;
+}
+function makemap(kv) {
+	var m = new Map();
+	for (var i = 0; i < kv.length; i += 2) {
+		m.set(mapkey(kv[i]), [kv[i], kv[i + 1]]);
+	}
+	return m;
+}
+function lookup(m, k) {
+	if (!(m instanceof Map)) {
+		fail("lookup in non-map");
+	}
+	var e = m.get(mapkey(k));
+	return e ? [e[1], 1] : [0, 0];
+}
+function mapdelete(m, k) {
+	lookup(m, k);
+	m.delete(mapkey(k));
+}
+function keys(m) {
+	lookup(m, 0);
+	return Array.from(m.values(), function(e) {
+		return e[0];
+	});
+}
+function slice(a, lo, hi) {
+	if (!(lo >= 0 && lo <= hi && hi <= a.length)) {
+		fail("slice bounds out of range [" + lo + ":" + hi + "] with length " + a.length);
+	}
+	return a.slice(Number(lo), Number(hi));
+}
+function concat(a, b) {
+	if (typeof a !== "string" || typeof b !== "string") {
+		fail("invalid operation: string + non-string");
+	}
+	return a + b;
+}
+function utf8(s) {
+	return new TextDecoder().decode(Uint8Array.from(s, function(c) {
+		return c.charCodeAt(0);
+	}));
+}
+function runestr(r) {
+	if (!(r >= 0 && r <= 0x10ffff) || r >= 0xd800 && r <= 0xdfff) {
+		r = 0xfffd;
+	}
+	r = Number(r);
+	if (r < 0x80) {
+		return String.fromCharCode(r);
+	}
+	if (r < 0x800) {
+		return String.fromCharCode(0xc0 | r >> 6, 0x80 | r & 0x3f);
+	}
+	if (r < 0x10000) {
+		return String.fromCharCode(0xe0 | r >> 12, 0x80 | r >> 6 & 0x3f, 0x80 | r & 0x3f);
+	}
+	return String.fromCharCode(0xf0 | r >> 18, 0x80 | r >> 12 & 0x3f, 0x80 | r >> 6 & 0x3f, 0x80 | r & 0x3f);
+}
+function decoderune(s, i) {
+	if (!(i >= 0 && i < s.length)) {
+		return [0xfffd, 0];
+	}
+	var c = s.charCodeAt(i);
+	var size, r;
+	if (c < 0x80) {
+		return [c, 1];
+	} else if (c >= 0xc2 && c <= 0xdf) {
+		size = 2, r = c & 0x1f;
+	} else if (c >= 0xe0 && c <= 0xef) {
+		size = 3, r = c & 0x0f;
+	} else if (c >= 0xf0 && c <= 0xf4) {
+		size = 4, r = c & 0x07;
+	} else {
+		return [0xfffd, 1];
+	}
+	if (i + size > s.length) {
+		return [0xfffd, 1];
+	}
+	for (var k = 1; k < size; k++) {
+		c = s.charCodeAt(i + k);
+		if ((c & 0xc0) !== 0x80) {
+			return [0xfffd, 1];
+		}
+		r = r << 6 | c & 0x3f;
+	}
+	if (size === 3 && (r < 0x800 || r >= 0xd800 && r <= 0xdfff) ||
+		size === 4 && (r < 0x10000 || r > 0x10ffff)) {
+		return [0xfffd, 1]; // overlong, surrogate, or too large
+	}
+	return [r, size];
+}
+function runes(s) {
+	var a = [];
+	for (var i = 0; i < s.length;) {
+		var d = decoderune(s, i);
+		a.push(d[0]);
+		i += d[1];
+	}
+	return a;
+}
+function range(a) {
+	if (a instanceof Map) {
+		return Array.from(a.values());
+	}
+	var p = [];
+	if (typeof a === "string") {
+		for (var i = 0; i < a.length;) {
+			var d = decoderune(a, i);
+			p.push([i, d[0]]);
+			i += d[1];
+		}
+		return p;
+	}
+	for (var i = 0; i < a.length; i++) {
+		p.push([i, a[i]]);
+	}
+	return p;
+}
+function itoa(n) {
+	return String(n);
+}
+function Big(n) {
+	this.n = n;
+}
+Big.prototype[Symbol.for("nodejs.util.inspect.custom")] = function() {
+	return String(this.n);
+};
+function show(x) {
+	if (x === undefined) {
+		return "nil";
+	}
+	if (typeof x === "string") {
+		return utf8(x);
+	}
+	if (typeof x === "bigint") {
+		return new Big(x);
+	}
+	if (x instanceof Map) {
+		return new Map(Array.from(x.values(), function(e) {
+			return e.map(show);
+		}));
+	}
+	return x instanceof Array ? x.map(show) : x;
+}
+function println() {
+	console.log.apply(console, Array.prototype.map.call(arguments, show));
+}
+function nargs(a) {
+	return a instanceof Array ? a.length : 0;
+}
+function checkargs(a, n) {
+	if (nargs(a) !== n) {
+		fail("wrong number of arguments: have " + nargs(a) + ", want " + n);
+	}
+}
+function rest(a, n) {
+	if (nargs(a) < n) {
+		fail("wrong number of arguments: have " + nargs(a) + ", want at least " + n);
+	}
+	return nargs(a) > 0 ? a.slice(n) : [];
+}
+function field(r, f) {
+	var i = r[0].split(",").indexOf(f);
+	if (i < 0) {
+		fail("record has no field " + f);
+	}
+	return r[i + 1];
+}
 `
 
-func Gen(exp cps.Exp, r cps.Var) string {
+// intPrelude has the integer operations for
+// integers that are JavaScript numbers,
+// exact up to 1<<53 - 1 in magnitude.
+const intPrelude = `
+function isint(a, b) {
+	return typeof a === "number" && typeof b === "number";
+}
+function checkint(n) {
+	if (!(n >= -9007199254740991 && n <= 9007199254740991)) {
+		fail("integer overflow");
+	}
+	return n + 0; // turn -0 into 0
+}
+function add(a, b) {
+	if (isint(a, b)) {
+		return checkint(a + b);
+	}
+	if (typeof a === "string" || typeof b === "string") {
+		return concat(a, b);
+	}
+	return new Float(+a + +b);
+}
+function sub(a, b) {
+	return isint(a, b) ? checkint(a - b) : new Float(a - b);
+}
+function mul(a, b) {
+	return isint(a, b) ? checkint(a * b) : new Float(a * b);
+}
+function quo(a, b) {
+	if (!isint(a, b)) {
+		return new Float(a / b);
+	}
+	if (b === 0) {
+		fail("integer divide by zero");
+	}
+	return checkint((a - a % b) / b);
+}
+function rem(a, b) {
+	if (!isint(a, b)) {
+		return new Float(a % b);
+	}
+	if (b === 0) {
+		fail("integer divide by zero");
+	}
+	return checkint(a % b);
+}
+function neg(a) {
+	return typeof a === "number" ? checkint(-a) : new Float(-a);
+}
+function toint(a) {
+	return checkint(Math.trunc(a));
+}
+function atoi(s) {
+	var n = Number(s);
+	if (!/^[+-]?[0-9]+$/.test(s) || !Number.isSafeInteger(n)) {
+		return [0, 0];
+	}
+	return [n + 0, 1];
+}
+`
+
+// bigIntPrelude has the integer operations for
+// unbounded integers. An integer is a JavaScript
+// number if it is no more than 1<<53 - 1 in magnitude,
+// and otherwise a BigInt, so small integers
+// are as fast as in intPrelude, and there is
+// only one representation of each integer.
+const bigIntPrelude = `
+function isint(a, b) {
+	return (typeof a === "number" || typeof a === "bigint") &&
+		(typeof b === "number" || typeof b === "bigint");
+}
+function small(a, b) {
+	return typeof a === "number" && typeof b === "number";
+}
+function norm(n) {
+	return n >= -9007199254740991n && n <= 9007199254740991n ? Number(n) : n;
+}
+function add(a, b) {
+	if (!isint(a, b)) {
+		if (typeof a === "string" || typeof b === "string") {
+			return concat(a, b);
+		}
+		return new Float(Number(a) + Number(b));
+	}
+	if (small(a, b) && Number.isSafeInteger(a + b)) {
+		return a + b;
+	}
+	return norm(BigInt(a) + BigInt(b));
+}
+function sub(a, b) {
+	if (!isint(a, b)) {
+		return new Float(Number(a) - Number(b));
+	}
+	if (small(a, b) && Number.isSafeInteger(a - b)) {
+		return a - b;
+	}
+	return norm(BigInt(a) - BigInt(b));
+}
+function mul(a, b) {
+	if (!isint(a, b)) {
+		return new Float(Number(a) * Number(b));
+	}
+	if (small(a, b) && Number.isSafeInteger(a * b)) {
+		return a * b + 0; // turn -0 into 0
+	}
+	return norm(BigInt(a) * BigInt(b));
+}
+function quo(a, b) {
+	if (!isint(a, b)) {
+		return new Float(Number(a) / Number(b));
+	}
+	if (b == 0) {
+		fail("integer divide by zero");
+	}
+	if (small(a, b)) {
+		return (a - a % b) / b + 0;
+	}
+	return norm(BigInt(a) / BigInt(b));
+}
+function rem(a, b) {
+	if (!isint(a, b)) {
+		return new Float(Number(a) % Number(b));
+	}
+	if (b == 0) {
+		fail("integer divide by zero");
+	}
+	if (small(a, b)) {
+		return a % b + 0;
+	}
+	return norm(BigInt(a) % BigInt(b));
+}
+function neg(a) {
+	if (typeof a === "number") {
+		return -a + 0;
+	}
+	return typeof a === "bigint" ? norm(-a) : new Float(-a);
+}
+function toint(a) {
+	if (!(a instanceof Float)) {
+		return a;
+	}
+	var t = Math.trunc(a);
+	if (!isFinite(t)) {
+		fail("integer overflow");
+	}
+	return Number.isSafeInteger(t) ? t + 0 : norm(BigInt(t));
+}
+function atoi(s) {
+	if (!/^[+-]?[0-9]+$/.test(s)) {
+		return [0, 0];
+	}
+	return [norm(BigInt(s)), 1];
+}
+`
+
+// A Mode controls the code made by Gen.
+type Mode uint
+
+const (
+	BigInts Mode = 1 << iota // integers are unbounded
+)
+
+func Gen(exp cps.Exp, r cps.Var, mode Mode) string {
 	s := `(function() {`
 	s += prelude
+	if mode&BigInts != 0 {
+		s += bigIntPrelude
+	} else {
+		s += intPrelude
+	}
 	s += "function " + jsvar(r) + "() { return []; };"
 	s += `drive([function() {`
 	s += gen(exp)
@@ -97,8 +559,21 @@ func genVal(v cps.Value) string {
 	switch v := v.(type) {
 	case cps.Int:
 		return strconv.Itoa(int(v))
+	case cps.Float:
+		f := float64(v)
+		switch {
+		case math.IsInf(f, 1):
+			return "new Float(Infinity)"
+		case math.IsInf(f, -1):
+			return "new Float(-Infinity)"
+		case math.IsNaN(f):
+			return "new Float(NaN)"
+		}
+		return "new Float(" + strconv.FormatFloat(f, 'g', -1, 64) + ")"
+	case cps.BigInt:
+		return string(v) + "n"
 	case cps.String:
-		return strconv.QuoteToASCII(string(v))
+		return jsstring(string(v))
 	case cps.Undefined:
 		return "undefined"
 	case cps.Var:
@@ -123,3 +598,23 @@ func genRec(vl []cps.RecordEnt) string {
 	}
 	return "[" + strings.Join(sl, ",") + "]"
 }
+
+// jsstring returns a JavaScript string literal
+// for s, with one character for each byte of s,
+// as Bubble strings are represented at run time.
+// Only printable ASCII appears unescaped, and not <,
+// so the literal is also safe in an HTML script element.
+func jsstring(s string) string {
+	b := []byte{'"'}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+		case c >= 0x20 && c < 0x7f && c != '<':
+			b = append(b, c)
+		default:
+			b = append(b, fmt.Sprintf(`\x%02x`, c)...)
+		}
+	}
+	return string(append(b, '"'))
+}
@@ -2,6 +2,7 @@ package naivegen
 
 import (
 	"log"
+	"strings"
 
 	"github.com/kr/bubble/prim"
 )
@@ -10,19 +11,101 @@ import (
 func genPrim(op prim.Op, dl, wl, cl []string) string {
 	switch op {
 	case prim.Println:
-		return `console.log.apply(null, ` + dl[0] + `);` + cl[0]
+		return `println(` + strings.Join(dl, `,`) + `);` + cl[0]
 	case prim.Add:
-		return `var ` + wl[0] + ` = ` + dl[0] + ` + ` + dl[1] + `;` + cl[0]
+		return `var ` + wl[0] + ` = add(` + dl[0] + `,` + dl[1] + `);` + cl[0]
 	case prim.Sub:
-		return `var ` + wl[0] + ` = ` + dl[0] + ` - ` + dl[1] + `;` + cl[0]
+		return `var ` + wl[0] + ` = sub(` + dl[0] + `,` + dl[1] + `);` + cl[0]
 	case prim.Mul:
-		return `var ` + wl[0] + ` = ` + dl[0] + ` * ` + dl[1] + `;` + cl[0]
+		return `var ` + wl[0] + ` = mul(` + dl[0] + `,` + dl[1] + `);` + cl[0]
 	case prim.Quo:
-		return `var ` + wl[0] + ` = ` + dl[0] + ` / ` + dl[1] + `;` + cl[0]
+		return `var ` + wl[0] + ` = quo(` + dl[0] + `,` + dl[1] + `);` + cl[0]
+	case prim.Rem:
+		return `var ` + wl[0] + ` = rem(` + dl[0] + `,` + dl[1] + `);` + cl[0]
+	case prim.Neg:
+		return `var ` + wl[0] + ` = neg(` + dl[0] + `);` + cl[0]
 	case prim.Lt:
 		return `if (` + dl[0] + ` < ` + dl[1] + `) { ` + cl[0] + ` } else { ` + cl[1] + ` }`
+	case prim.Leq:
+		return `if (` + dl[0] + ` <= ` + dl[1] + `) { ` + cl[0] + ` } else { ` + cl[1] + ` }`
+	case prim.Gt:
+		return `if (` + dl[0] + ` > ` + dl[1] + `) { ` + cl[0] + ` } else { ` + cl[1] + ` }`
+	case prim.Geq:
+		return `if (` + dl[0] + ` >= ` + dl[1] + `) { ` + cl[0] + ` } else { ` + cl[1] + ` }`
+	case prim.Eql:
+		return `if (eql(` + dl[0] + `,` + dl[1] + `)) { ` + cl[0] + ` } else { ` + cl[1] + ` }`
 	case prim.Ineq:
-		return `if (` + dl[0] + ` !== ` + dl[1] + `) { ` + cl[0] + ` } else { ` + cl[1] + ` }`
+		return `if (!eql(` + dl[0] + `,` + dl[1] + `)) { ` + cl[0] + ` } else { ` + cl[1] + ` }`
+	case prim.Boxed:
+		return `if (typeof ` + dl[0] + ` === "object") { ` + cl[0] + ` } else { ` + cl[1] + ` }`
+	case prim.Makeref:
+		return `var ` + wl[0] + ` = [` + dl[0] + `];` + cl[0]
+	case prim.Deref:
+		return `var ` + wl[0] + ` = ` + dl[0] + `[0];` + cl[0]
+	case prim.Assign:
+		return dl[0] + `[0] = ` + dl[1] + `;` + cl[0]
+	case prim.List:
+		return `var ` + wl[0] + ` = [` + strings.Join(dl, `,`) + `];` + cl[0]
+	case prim.Index:
+		return `var ` + wl[0] + ` = index(` + dl[0] + `,` + dl[1] + `);` + cl[0]
+	case prim.IndexZero:
+		return `var ` + wl[0] + ` = indexzero(` + dl[0] + `,` + dl[1] + `);` + cl[0]
+	case prim.SetIndex:
+		return `setindex(` + dl[0] + `,` + dl[1] + `,` + dl[2] + `);` + cl[0]
+	case prim.Len:
+		return `var ` + wl[0] + ` = len(` + dl[0] + `);` + cl[0]
+	case prim.CheckArgs:
+		return `checkargs(` + dl[0] + `,` + dl[1] + `);` + cl[0]
+	case prim.Rest:
+		return `var ` + wl[0] + ` = rest(` + dl[0] + `,` + dl[1] + `);` + cl[0]
+	case prim.Field:
+		return `var ` + wl[0] + ` = field(` + dl[0] + `,` + dl[1] + `);` + cl[0]
+	case prim.ToInt:
+		return `var ` + wl[0] + ` = toint(` + dl[0] + `);` + cl[0]
+	case prim.ToFloat:
+		return `var ` + wl[0] + ` = new Float(Number(` + dl[0] + `));` + cl[0]
+	case prim.Slice:
+		return `var ` + wl[0] + ` = slice(` + dl[0] + `,` + dl[1] + `,` + dl[2] + `);` + cl[0]
+	case prim.Itoa:
+		return `var ` + wl[0] + ` = itoa(` + dl[0] + `);` + cl[0]
+	case prim.Atoi:
+		return `var ` + wl[0] + ` = atoi(` + dl[0] + `);` + cl[0]
+	case prim.RuneStr:
+		return `var ` + wl[0] + ` = runestr(` + dl[0] + `);` + cl[0]
+	case prim.Runes:
+		return `var ` + wl[0] + ` = runes(` + dl[0] + `);` + cl[0]
+	case prim.DecodeRune:
+		return `var ` + wl[0] + ` = decoderune(` + dl[0] + `,` + dl[1] + `);` + cl[0]
+	case prim.Range:
+		return `var ` + wl[0] + ` = range(` + dl[0] + `);` + cl[0]
+	case prim.MakeMap:
+		return `var ` + wl[0] + ` = makemap([` + strings.Join(dl, `,`) + `]);` + cl[0]
+	case prim.Lookup:
+		return `var ` + wl[0] + ` = lookup(` + dl[0] + `,` + dl[1] + `);` + cl[0]
+	case prim.Delete:
+		return `mapdelete(` + dl[0] + `,` + dl[1] + `);` + cl[0]
+	case prim.Keys:
+		return `var ` + wl[0] + ` = keys(` + dl[0] + `);` + cl[0]
+	case prim.Enter:
+		return `var ` + wl[0] + ` = frames.push(` + dl[0] + `) - 1;` + cl[0]
+	case prim.Leave:
+		return `frames.length = ` + dl[0] + `;` + cl[0]
+	case prim.Handler:
+		return `var ` + wl[0] + ` = handler;` + cl[0]
+	case prim.SetHandler:
+		return `handler = ` + dl[0] + `;` + cl[0]
+	case prim.Panic:
+		return `throw new Panic(` + dl[0] + `);`
+	case prim.Repanic:
+		return `if (panicking) { throw panicking; }` + cl[0]
+	case prim.Recover:
+		return `var ` + wl[0] + ` = recover(` + dl[0] + `);` + cl[0]
+	case prim.DeferID:
+		return `var ` + wl[0] + ` = deferring;` + cl[0]
+	case prim.SetDeferID:
+		return `deferring = ` + dl[0] + `;` + cl[0]
+	case prim.Append:
+		return `var ` + wl[0] + ` = ` + dl[0] + `.concat([` + strings.Join(dl[1:], `,`) + `]);` + cl[0]
 	}
 	log.Fatalf("unhandled %v\n", op)
 	panic("unreached")
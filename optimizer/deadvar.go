@@ -22,7 +22,9 @@ func deadVar1(exp cps.Exp) cps.Exp {
 			}
 			free := false
 			for _, w := range exp.Ws {
-				isFree(exp, w)
+				if isFree(exp, w) {
+					free = true
+				}
 			}
 			if !free && len(exp.Es) == 1 {
 				return exp.Es[0]
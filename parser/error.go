@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"io"
+	"sort"
+)
+
+// An Error is a syntax error.
+// If the parser was looking for a particular token,
+// Want is that token and Got is the token it found instead;
+// otherwise both are token.ILLEGAL.
+type Error struct {
+	Pos  token.Position
+	Msg  string
+	Want token.Token
+	Got  token.Token
+}
+
+func (e *Error) Error() string {
+	if e.Pos.Filename != "" || e.Pos.IsValid() {
+		return e.Pos.String() + ": " + e.Msg
+	}
+	return e.Msg
+}
+
+// ErrorList is a list of syntax errors.
+// Parse returns an ErrorList sorted by position.
+type ErrorList []*Error
+
+func (p ErrorList) Len() int      { return len(p) }
+func (p ErrorList) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+
+func (p ErrorList) Less(i, j int) bool {
+	e, f := p[i].Pos, p[j].Pos
+	if e.Filename != f.Filename {
+		return e.Filename < f.Filename
+	}
+	if e.Line != f.Line {
+		return e.Line < f.Line
+	}
+	return e.Column < f.Column
+}
+
+func (p ErrorList) Error() string {
+	switch len(p) {
+	case 0:
+		return "no errors"
+	case 1:
+		return p[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", p[0], len(p)-1)
+}
+
+// Err returns an error equivalent to p,
+// or nil if p is empty.
+func (p ErrorList) Err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	sort.Sort(p)
+	return p
+}
+
+// PrintError prints err to w, one line per error
+// if err is an ErrorList or a scanner.ErrorList.
+func PrintError(w io.Writer, err error) {
+	if list, ok := err.(ErrorList); ok {
+		for _, e := range list {
+			fmt.Fprintln(w, e)
+		}
+		return
+	}
+	scanner.PrintError(w, err)
+}
@@ -1,13 +1,11 @@
 package parser
 
 import (
-	"errors"
 	"fmt"
 	"go/scanner"
 	"go/token"
 	"io/ioutil"
 	"os"
-	"strings"
 
 	"github.com/kr/bubble/ast"
 )
@@ -26,8 +24,16 @@ type parser struct {
 	pos token.Pos
 	tok token.Token
 	lit string
+
+	errors  ErrorList
+	pkgName string
 }
 
+// bailout is panicked by the parser to abandon
+// the current statement or declaration
+// after reporting a syntax error.
+type bailout struct{}
+
 func (p *parser) next() {
 	p.pos, p.tok, p.lit = p.scanner.Scan()
 	if p.mode&Debug != 0 {
@@ -36,18 +42,21 @@ func (p *parser) next() {
 	}
 }
 
-func (p *parser) want(tok token.Token) {
+// want consumes a token of kind tok
+// and returns its position.
+func (p *parser) want(tok token.Token) token.Pos {
+	pos := p.pos
 	if p.tok != tok {
-		// TODO(kr): don't crash here
-		p.errorf("error tok = %v want %v", p.tok, tok)
+		p.errorExpected(tok)
 	}
 	p.next()
+	return pos
 }
 
-func handleError(pos token.Position, msg string) {
-	fmt.Fprintln(os.Stderr, "error", pos, msg)
-}
-
+// Parse parses the files in fset.
+// If there are syntax errors, Parse returns
+// as much of the package as it could read
+// along with an ErrorList describing every error.
 func Parse(fset *token.FileSet, mode Mode) (*ast.Package, error) {
 	var p parser
 	p.mode = mode
@@ -61,18 +70,13 @@ func Parse(fset *token.FileSet, mode Mode) (*ast.Package, error) {
 			return false
 		}
 		pkg.Files = append(pkg.Files, file)
-		pkg.Name = file.Name.Name
 		return true
 	})
 	if err != nil {
 		return nil, err
 	}
-	for _, f := range pkg.Files {
-		if f.Name.Name != pkg.Name {
-			return nil, errors.New("multiple packages: " + pkg.Name + " " + f.Name.Name)
-		}
-	}
-	return pkg, nil
+	pkg.Name = p.pkgName
+	return pkg, p.errors.Err()
 }
 
 func (p *parser) parseFile(f *token.File) (*ast.File, error) {
@@ -80,103 +84,397 @@ func (p *parser) parseFile(f *token.File) (*ast.File, error) {
 	if err != nil {
 		return nil, err
 	}
-	p.scanner.Init(f, text, handleError, 0)
+	p.scanner.Init(f, text, p.error, 0)
 	file := new(ast.File)
 	p.next()
+	file.Package = p.pos
+	file.Name = p.parsePackageClause()
+	for p.tok != token.EOF {
+		p.parseDecl(file)
+	}
+	return file, nil
+}
+
+func (p *parser) parsePackageClause() (name *ast.Ident) {
+	name = &ast.Ident{NamePos: p.pos, Name: "_"}
+	defer p.catch(p.syncDecl)
 	p.want(token.PACKAGE)
-	file.Name = &ast.Ident{p.lit}
-	p.want(token.IDENT)
+	name = p.parseIdent()
+	if p.pkgName == "" {
+		p.pkgName = name.Name
+	} else if name.Name != p.pkgName {
+		p.error(p.fileSet.Position(name.Pos()), "package "+name.Name+"; expected "+p.pkgName)
+	}
 	p.want(token.SEMICOLON)
-	for p.tok == token.IMPORT {
+	return name
+}
+
+// parseDecl parses a top-level declaration
+// and adds it to file.
+func (p *parser) parseDecl(file *ast.File) {
+	defer p.catch(p.syncDecl)
+	switch p.tok {
+	case token.IMPORT:
+		pos := p.fileSet.Position(p.pos)
 		imps := p.parseImportStmt()
+		if len(file.Funcs) > 0 || len(file.Methods) > 0 || len(file.Types) > 0 || len(file.Values) > 0 {
+			p.error(pos, "import after declaration")
+		}
 		file.Imports = append(file.Imports, imps...)
-		p.want(token.SEMICOLON)
-	}
-	for {
-		switch p.tok {
-		case token.FUNC:
-			x, err := p.parseFuncDecl()
-			if err != nil {
-				return nil, err
-			}
-			file.Funcs = append(file.Funcs, x)
-			p.want(token.SEMICOLON)
-		case token.EOF:
-			return file, nil
-		case token.IMPORT:
-			p.errorf("import after declaration")
-		default:
-			// TODO(kr): don't crash here
-			p.errorf("unexpected: %v", p.tok)
+	case token.FUNC:
+		if f := p.parseFuncDecl(); f.Recv != nil {
+			file.Methods = append(file.Methods, f)
+		} else {
+			file.Funcs = append(file.Funcs, f)
 		}
+	case token.TYPE:
+		file.Types = append(file.Types, p.parseTypeDecl())
+	case token.VAR, token.CONST:
+		file.Values = append(file.Values, p.parseGenDecl())
+	default:
+		p.errorf("expected declaration, found %s", tokString(p.tok, p.lit))
 	}
+	p.want(token.SEMICOLON)
 }
 
 func (p *parser) parseImportStmt() []*ast.ImportSpec {
 	p.want(token.IMPORT)
-	// TODO(kr): imports grouped with parentheses
+	if p.tok != token.LPAREN {
+		return []*ast.ImportSpec{p.parseImportSpec()}
+	}
+	p.next()
+	var a []*ast.ImportSpec
+	for p.tok != token.RPAREN && p.tok != token.EOF {
+		a = append(a, p.parseImportSpec())
+		if p.tok == token.RPAREN {
+			break
+		}
+		p.want(token.SEMICOLON)
+	}
+	p.want(token.RPAREN)
+	return a
+}
+
+// parseImportSpec parses one import,
+// with an optional name, _, or . before the path.
+func (p *parser) parseImportSpec() *ast.ImportSpec {
 	var name *ast.Ident
-	if p.tok == token.IDENT {
-		name = &ast.Ident{p.lit}
+	switch p.tok {
+	case token.IDENT:
+		name = p.parseIdent()
+	case token.PERIOD:
+		name = &ast.Ident{NamePos: p.pos, Name: "."}
 		p.next()
 	}
-	path := &ast.BasicLit{p.tok, p.lit}
+	path := &ast.BasicLit{ValuePos: p.pos, Kind: p.tok, Value: p.lit}
 	p.want(token.STRING)
-	return []*ast.ImportSpec{{Name: name, Path: path}}
+	return &ast.ImportSpec{Name: name, Path: path}
 }
 
-func (p *parser) parseFuncDecl() (*ast.FuncDecl, error) {
-	p.want(token.FUNC)
-	name := &ast.Ident{p.lit}
-	p.want(token.IDENT)
-	params := p.parseVarList()
+func (p *parser) parseFuncDecl() *ast.FuncDecl {
+	pos := p.want(token.FUNC)
+	var recv, recvType *ast.Ident
+	if p.tok == token.LPAREN {
+		p.next()
+		recv = p.parseIdent()
+		recvType = p.parseIdent()
+		p.want(token.RPAREN)
+	}
+	name := p.parseIdent()
+	params, types, ellipsis := p.parseParams()
+	result := p.parseResult()
 	body := p.parseBlockStmt()
-	return &ast.FuncDecl{Name: name, Params: params, Body: body}, nil
+	return &ast.FuncDecl{
+		Func:     pos,
+		Recv:     recv,
+		RecvType: recvType,
+		Name:     name,
+		Params:   params,
+		Types:    types,
+		Ellipsis: ellipsis,
+		Result:   result,
+		Body:     body,
+	}
+}
+
+func (p *parser) parseGenDecl() *ast.GenDecl {
+	d := &ast.GenDecl{TokPos: p.pos, Tok: p.tok}
+	p.next()
+	if p.tok != token.LPAREN {
+		d.Specs = []*ast.ValueSpec{p.parseValueSpec(d.Tok, 0)}
+		return d
+	}
+	d.Lparen = p.pos
+	p.next()
+	for p.tok != token.RPAREN && p.tok != token.EOF {
+		d.Specs = append(d.Specs, p.parseValueSpec(d.Tok, len(d.Specs)))
+		if p.tok == token.RPAREN {
+			break
+		}
+		p.want(token.SEMICOLON)
+	}
+	d.Rparen = p.want(token.RPAREN)
+	return d
+}
+
+// parseValueSpec parses spec i of a var or const declaration,
+// as given by tok.
+// Only a const spec after the first in a group
+// may leave out its values.
+func (p *parser) parseValueSpec(tok token.Token, i int) *ast.ValueSpec {
+	s := &ast.ValueSpec{Names: []*ast.Ident{p.parseIdent()}}
+	for p.tok == token.COMMA {
+		p.next()
+		s.Names = append(s.Names, p.parseIdent())
+	}
+	if tok == token.CONST && i > 0 && p.tok != token.ASSIGN {
+		return s
+	}
+	p.want(token.ASSIGN)
+	s.Values = p.parseExprList()
+	return s
+}
+
+func (p *parser) parseTypeDecl() *ast.TypeDecl {
+	d := &ast.TypeDecl{Type: p.want(token.TYPE)}
+	d.Name = p.parseIdent()
+	if p.tok == token.INTERFACE {
+		d.Interface = p.pos
+		p.next()
+		d.Lbrace = p.want(token.LBRACE)
+		for p.tok != token.RBRACE {
+			m := &ast.Field{Name: p.parseIdent()}
+			x := &ast.FuncType{Func: m.Name.Pos()}
+			p.parseSignature(x)
+			m.Value = x
+			d.Methods = append(d.Methods, m)
+			if p.tok == token.RBRACE {
+				break
+			}
+			p.want(token.SEMICOLON)
+		}
+		d.Rbrace = p.want(token.RBRACE)
+		return d
+	}
+	d.Lbrace = p.want(token.LBRACE)
+	for p.tok != token.RBRACE {
+		c := &ast.ConSpec{Name: p.parseIdent()}
+		if p.tok == token.LPAREN {
+			c.Params, c.Rparen = p.parseVarList()
+		}
+		d.Cons = append(d.Cons, c)
+		if p.tok == token.RBRACE {
+			break
+		}
+		p.want(token.SEMICOLON)
+	}
+	d.Rbrace = p.want(token.RBRACE)
+	return d
 }
 
-func (p *parser) parseVarList() (a []*ast.Ident) {
+// parseParams parses the parameters of a function.
+// The last one may be followed by "...",
+// whose position is returned as ellipsis.
+// Each may be followed by its type, returned in types,
+// which is nil if none of them has one.
+func (p *parser) parseParams() (a []*ast.Ident, types []ast.Expr, ellipsis token.Pos) {
 	p.want(token.LPAREN)
+	typed := false
 	for p.tok != token.RPAREN {
-		lit := p.lit
-		p.want(token.IDENT)
-		a = append(a, &ast.Ident{lit})
+		a = append(a, p.parseIdent())
+		if p.tok == token.ELLIPSIS {
+			ellipsis = p.pos
+			p.next()
+		}
+		var t ast.Expr
+		if p.tok != token.COMMA && p.tok != token.RPAREN {
+			t = p.parseType()
+			typed = true
+		}
+		types = append(types, t)
+		if ellipsis.IsValid() && p.tok != token.RPAREN {
+			p.errorf("can only use ... with final parameter in list")
+		}
 		if p.tok == token.RPAREN {
 			break
 		}
 		p.want(token.COMMA)
 	}
 	p.want(token.RPAREN)
-	return a
+	if !typed {
+		types = nil
+	}
+	return a, types, ellipsis
+}
+
+// parseResult parses the result type of a function,
+// or returns nil if the next token cannot begin one.
+// A record type must be in parentheses here,
+// so that it is not taken for the function's body.
+func (p *parser) parseResult() ast.Expr {
+	switch p.tok {
+	case token.IDENT, token.LBRACK, token.MAP, token.FUNC, token.LPAREN:
+		return p.parseType()
+	}
+	return nil
+}
+
+// parseType parses a type in an annotation.
+// In parentheses, it may be a list of types,
+// for the result of a function returning several values.
+func (p *parser) parseType() ast.Expr {
+	switch pos := p.pos; p.tok {
+	case token.IDENT:
+		id := p.parseIdent()
+		if p.tok != token.PERIOD {
+			return id
+		}
+		p.next()
+		return &ast.SelectorExpr{X: id, Sel: p.parseIdent()}
+	case token.LBRACK:
+		p.next()
+		p.want(token.RBRACK)
+		return &ast.ListType{Lbrack: pos, Elem: p.parseType()}
+	case token.MAP:
+		p.next()
+		p.want(token.LBRACK)
+		key := p.parseType()
+		p.want(token.RBRACK)
+		return &ast.MapType{Map: pos, Key: key, Value: p.parseType()}
+	case token.FUNC:
+		return p.parseFuncType()
+	case token.LBRACE:
+		return p.parseRecordType()
+	case token.LPAREN:
+		p.next()
+		x := &ast.TupleType{Lparen: pos, Types: []ast.Expr{p.parseType()}}
+		for p.tok == token.COMMA {
+			p.next()
+			x.Types = append(x.Types, p.parseType())
+		}
+		x.Rparen = p.want(token.RPAREN)
+		if len(x.Types) == 1 {
+			return x.Types[0]
+		}
+		return x
+	}
+	p.errorf("expected type, found %s", tokString(p.tok, p.lit))
+	panic("unreached")
+}
+
+func (p *parser) parseFuncType() *ast.FuncType {
+	x := &ast.FuncType{Func: p.want(token.FUNC)}
+	p.parseSignature(x)
+	return x
+}
+
+// parseSignature parses the param types
+// and result type of x.
+func (p *parser) parseSignature(x *ast.FuncType) {
+	p.want(token.LPAREN)
+	for p.tok != token.RPAREN {
+		if p.tok == token.ELLIPSIS {
+			x.Ellipsis = p.pos
+			p.next()
+		}
+		x.Params = append(x.Params, p.parseType())
+		if x.Ellipsis.IsValid() && p.tok != token.RPAREN {
+			p.errorf("can only use ... with final parameter in list")
+		}
+		if p.tok == token.RPAREN {
+			break
+		}
+		p.want(token.COMMA)
+	}
+	x.Rparen = p.want(token.RPAREN)
+	x.Result = p.parseResult()
+}
+
+func (p *parser) parseRecordType() *ast.RecordType {
+	x := &ast.RecordType{Lbrace: p.want(token.LBRACE)}
+	for p.tok != token.RBRACE {
+		if p.tok == token.ELLIPSIS {
+			x.Ellipsis = p.pos
+			p.next()
+			break
+		}
+		f := &ast.Field{Name: p.parseIdent()}
+		f.Colon = p.want(token.COLON)
+		f.Value = p.parseType()
+		x.Fields = append(x.Fields, f)
+		if p.tok == token.RBRACE {
+			break
+		}
+		p.want(token.COMMA)
+	}
+	x.Rbrace = p.want(token.RBRACE)
+	return x
+}
+
+func (p *parser) parseVarList() (a []*ast.Ident, rparen token.Pos) {
+	p.want(token.LPAREN)
+	for p.tok != token.RPAREN {
+		a = append(a, p.parseIdent())
+		if p.tok == token.RPAREN {
+			break
+		}
+		p.want(token.COMMA)
+	}
+	return a, p.want(token.RPAREN)
 }
 
 func (p *parser) parseBlockStmt() *ast.BlockStmt {
-	p.want(token.LBRACE)
 	body := new(ast.BlockStmt)
-	for p.tok != token.RBRACE {
-		stmt := p.parseStmt()
-		body.List = append(body.List, stmt)
+	body.Lbrace = p.want(token.LBRACE)
+	for p.tok != token.RBRACE && p.tok != token.EOF {
+		if stmt := p.parseStmt(); stmt != nil {
+			body.List = append(body.List, stmt)
+		}
 	}
-	p.want(token.RBRACE)
+	body.Rbrace = p.want(token.RBRACE)
 	return body
 }
 
-func (p *parser) parseStmt() ast.Stmt {
-	defer p.want(token.SEMICOLON)
+// parseStmt parses a statement.
+// It returns nil if the statement has a syntax error.
+func (p *parser) parseStmt() (s ast.Stmt) {
+	defer p.catch(p.syncStmt)
 	switch p.tok {
 	case token.IF:
-		return p.parseIf()
+		s = p.parseIf()
+	case token.FOR:
+		s = p.parseFor()
+	case token.SWITCH:
+		s = p.parseSwitch()
 	case token.RETURN:
-		return p.parseReturn()
+		s = p.parseReturn()
+	case token.DEFER:
+		s = p.parseDefer()
+	case token.BREAK, token.CONTINUE:
+		s = p.parseBranch()
 	default:
-		return p.parseExprStmt()
+		s = p.parseSimpleStmt(false)
+		if x, ok := s.(*ast.ExprStmt); ok && p.tok == token.COLON {
+			if label, ok := x.X.(*ast.Ident); ok {
+				colon := p.pos
+				p.next()
+				stmt := p.parseStmt()
+				if stmt == nil {
+					return nil
+				}
+				return &ast.LabeledStmt{Label: label, Colon: colon, Stmt: stmt}
+			}
+		}
 	}
+	p.want(token.SEMICOLON)
+	return s
 }
 
 func (p *parser) parseIf() ast.Stmt {
-	p.want(token.IF)
+	pos := p.want(token.IF)
 	cond := p.parseExpr()
 	body := p.parseBlockStmt()
-	s := &ast.IfStmt{Cond: cond, Body: body}
+	s := &ast.IfStmt{If: pos, Cond: cond, Body: body}
 	if p.tok == token.ELSE {
 		p.next()
 		switch p.tok {
@@ -191,42 +489,194 @@ func (p *parser) parseIf() ast.Stmt {
 	return s
 }
 
+func (p *parser) parseFor() *ast.ForStmt {
+	s := &ast.ForStmt{For: p.want(token.FOR)}
+	if p.tok == token.RANGE {
+		p.next()
+		s.X = p.parseExpr()
+	} else if p.tok != token.LBRACE {
+		var init ast.Stmt
+		if p.tok != token.SEMICOLON {
+			init = p.parseSimpleStmt(true)
+		}
+		if a, ok := init.(*ast.AssignStmt); ok && isRange(a.Rhs[0]) {
+			if len(a.Lhs) > 2 {
+				p.error(p.fileSet.Position(a.Lhs[2].Pos()), "expected at most 2 expressions")
+			}
+			s.Key, s.Tok, s.X = a.Lhs[0], a.Tok, a.Rhs[0].(*ast.UnaryExpr).X
+			if len(a.Lhs) > 1 {
+				s.Value = a.Lhs[1]
+			}
+		} else if p.tok == token.SEMICOLON {
+			p.next()
+			s.Init = init
+			if p.tok != token.SEMICOLON {
+				s.Cond = p.parseExpr()
+			}
+			p.want(token.SEMICOLON)
+			if p.tok != token.LBRACE {
+				s.Post = p.parseSimpleStmt(false)
+			}
+		} else if x, ok := init.(*ast.ExprStmt); ok {
+			s.Cond = x.X
+		} else {
+			p.errorf("expected for loop condition")
+		}
+	}
+	s.Body = p.parseBlockStmt()
+	return s
+}
+
+// isRange returns whether x is a range clause,
+// as returned by parseSimpleStmt.
+func isRange(x ast.Expr) bool {
+	u, ok := x.(*ast.UnaryExpr)
+	return ok && u.Op == token.RANGE
+}
+
+func (p *parser) parseSwitch() *ast.SwitchStmt {
+	s := &ast.SwitchStmt{Switch: p.want(token.SWITCH)}
+	s.Tag = p.parseExpr()
+	s.Lbrace = p.want(token.LBRACE)
+	for p.tok == token.CASE || p.tok == token.DEFAULT {
+		s.Cases = append(s.Cases, p.parseCaseClause())
+	}
+	s.Rbrace = p.want(token.RBRACE)
+	return s
+}
+
+func (p *parser) parseCaseClause() *ast.CaseClause {
+	c := &ast.CaseClause{Case: p.pos}
+	if p.tok == token.CASE {
+		p.next()
+		c.List = p.parseExprList()
+	} else {
+		p.want(token.DEFAULT)
+	}
+	c.Colon = p.want(token.COLON)
+	for p.tok != token.CASE && p.tok != token.DEFAULT && p.tok != token.RBRACE && p.tok != token.EOF {
+		if stmt := p.parseStmt(); stmt != nil {
+			c.Body = append(c.Body, stmt)
+		}
+	}
+	return c
+}
+
+func (p *parser) parseBranch() *ast.BranchStmt {
+	s := &ast.BranchStmt{TokPos: p.pos, Tok: p.tok}
+	p.next()
+	if p.tok == token.IDENT {
+		s.Label = p.parseIdent()
+	}
+	return s
+}
+
+func (p *parser) parseDefer() *ast.DeferStmt {
+	s := &ast.DeferStmt{Defer: p.want(token.DEFER)}
+	pos := p.pos
+	call, ok := p.parseExpr().(*ast.CallExpr)
+	if !ok {
+		p.error(p.fileSet.Position(pos), "expression in defer must be function call")
+		panic(bailout{})
+	}
+	s.Call = call
+	return s
+}
+
 func (p *parser) parseReturn() *ast.ReturnStmt {
-	p.want(token.RETURN)
-	x := p.parseExpr()
-	return &ast.ReturnStmt{x}
+	s := &ast.ReturnStmt{Return: p.want(token.RETURN)}
+	if p.tok != token.SEMICOLON && p.tok != token.RBRACE {
+		s.Results = p.parseExprList()
+	}
+	return s
 }
 
-func (p *parser) parseExprStmt() ast.Stmt {
-	x := p.parseExpr()
+// parseSimpleStmt parses an expression statement,
+// assignment, or increment or decrement statement.
+// If rangeOk is set, the right side of an assignment
+// may instead be a range clause, "range x", which
+// is returned as a UnaryExpr with Op token.RANGE.
+func (p *parser) parseSimpleStmt(rangeOk bool) ast.Stmt {
+	pos := p.pos
+	x := p.parseExprList()
 	switch p.tok {
 	case token.DEFINE, token.ASSIGN:
-		tok := p.tok
+		pos, tok := p.pos, p.tok
+		p.next()
+		if rangeOk && p.tok == token.RANGE {
+			y := &ast.UnaryExpr{OpPos: p.pos, Op: token.RANGE}
+			p.next()
+			y.X = p.parseExpr()
+			return &ast.AssignStmt{Lhs: x, TokPos: pos, Tok: tok, Rhs: []ast.Expr{y}}
+		}
+		y := p.parseExprList()
+		return &ast.AssignStmt{Lhs: x, TokPos: pos, Tok: tok, Rhs: y}
+	}
+	if len(x) > 1 {
+		p.error(p.fileSet.Position(pos), "expected 1 expression")
+	}
+	switch p.tok {
+	case token.INC, token.DEC:
+		s := &ast.IncDecStmt{X: x[0], TokPos: p.pos, Tok: p.tok}
+		p.next()
+		return s
+	}
+	return &ast.ExprStmt{X: x[0]}
+}
+
+func (p *parser) parseExprList() []ast.Expr {
+	list := []ast.Expr{p.parseExpr()}
+	for p.tok == token.COMMA {
 		p.next()
-		y := p.parseExpr()
-		return &ast.AssignStmt{Lhs: x, Tok: tok, Rhs: y}
+		list = append(list, p.parseExpr())
 	}
-	return &ast.ExprStmt{x}
+	return list
 }
 
 func (p *parser) parseExpr() ast.Expr {
-	e := p.parseTerm()
-	for p.tok == token.ADD || p.tok == token.SUB {
-		t := p.tok
+	return p.parseBinaryExpr(token.LowestPrec + 1)
+}
+
+// binaryOps is the set of binary operators.
+// Their precedence is the same as in Go.
+var binaryOps = map[token.Token]bool{
+	token.LOR:  true,
+	token.LAND: true,
+	token.EQL:  true,
+	token.NEQ:  true,
+	token.LSS:  true,
+	token.LEQ:  true,
+	token.GTR:  true,
+	token.GEQ:  true,
+	token.ADD:  true,
+	token.SUB:  true,
+	token.MUL:  true,
+	token.QUO:  true,
+	token.REM:  true,
+}
+
+// parseBinaryExpr parses an expression
+// whose binary operators all have precedence
+// at least prec1.
+func (p *parser) parseBinaryExpr(prec1 int) ast.Expr {
+	x := p.parseUnaryExpr()
+	for binaryOps[p.tok] && p.tok.Precedence() >= prec1 {
+		pos, op := p.pos, p.tok
 		p.next()
-		e = &ast.BinaryExpr{X: e, Op: t, Y: p.parseTerm()}
+		y := p.parseBinaryExpr(op.Precedence() + 1)
+		x = &ast.BinaryExpr{X: x, OpPos: pos, Op: op, Y: y}
 	}
-	return e
+	return x
 }
 
-func (p *parser) parseTerm() ast.Expr {
-	e := p.parsePrimary()
-	for p.tok == token.MUL || p.tok == token.QUO {
-		t := p.tok
+func (p *parser) parseUnaryExpr() ast.Expr {
+	switch p.tok {
+	case token.SUB, token.NOT:
+		pos, op := p.pos, p.tok
 		p.next()
-		e = &ast.BinaryExpr{X: e, Op: t, Y: p.parsePrimary()}
+		return &ast.UnaryExpr{OpPos: pos, Op: op, X: p.parseUnaryExpr()}
 	}
-	return e
+	return p.parsePrimary()
 }
 
 // primary expression (selector, call, etc)
@@ -235,70 +685,258 @@ func (p *parser) parsePrimary() ast.Expr {
 	for {
 		switch p.tok {
 		case token.LPAREN:
-			x = &ast.CallExpr{Fun: x, Args: p.parseArgList()}
+			x = p.parseCall(x)
+		case token.LBRACK:
+			x = p.parseIndexOrSlice(x)
 		case token.PERIOD:
 			p.next()
-			lit := p.lit
-			p.want(token.IDENT)
-			x = &ast.SelectorExpr{X: x, Sel: &ast.Ident{lit}}
+			x = &ast.SelectorExpr{X: x, Sel: p.parseIdent()}
 		default:
 			return x
 		}
 	}
 }
 
-func (p *parser) parseArgList() (a []ast.Expr) {
-	p.want(token.LPAREN)
+func (p *parser) parseIndexOrSlice(x ast.Expr) ast.Expr {
+	lbrack := p.want(token.LBRACK)
+	var index ast.Expr
+	if p.tok != token.COLON {
+		index = p.parseExpr()
+	}
+	if p.tok != token.COLON {
+		rbrack := p.want(token.RBRACK)
+		return &ast.IndexExpr{X: x, Lbrack: lbrack, Index: index, Rbrack: rbrack}
+	}
+	p.next()
+	s := &ast.SliceExpr{X: x, Lbrack: lbrack, Low: index}
+	if p.tok != token.RBRACK {
+		s.High = p.parseExpr()
+	}
+	s.Rbrack = p.want(token.RBRACK)
+	return s
+}
+
+func (p *parser) parseCall(f ast.Expr) *ast.CallExpr {
+	call := &ast.CallExpr{Fun: f}
+	call.Lparen = p.want(token.LPAREN)
 	for p.tok != token.RPAREN {
-		a = append(a, p.parseExpr())
+		call.Args = append(call.Args, p.parseExpr())
 		if p.tok == token.RPAREN {
 			break
 		}
 		p.want(token.COMMA)
 	}
-	p.want(token.RPAREN)
-	return a
+	call.Rparen = p.want(token.RPAREN)
+	return call
+}
+
+func (p *parser) parseIdent() *ast.Ident {
+	pos, lit := p.pos, p.lit
+	p.want(token.IDENT)
+	return &ast.Ident{NamePos: pos, Name: lit}
 }
 
 func (p *parser) parseAtom() ast.Expr {
-	switch tok, lit := p.tok, p.lit; tok {
+	switch pos, tok, lit := p.pos, p.tok, p.lit; tok {
 	case token.IDENT:
+		return p.parseIdent()
+	case token.INT, token.FLOAT, token.CHAR, token.STRING:
 		p.next()
-		return &ast.Ident{lit}
-	case token.INT, token.STRING:
-		p.next()
-		return &ast.BasicLit{tok, lit}
+		return &ast.BasicLit{ValuePos: pos, Kind: tok, Value: lit}
 	case token.FUNC:
 		return p.parseFuncLit()
+	case token.LBRACK:
+		return p.parseListLit()
+	case token.LBRACE:
+		return p.parseRecordLit()
+	case token.MAP:
+		return p.parseMapLit()
 	case token.AND:
 		p.next()
 		body := p.parseExpr()
-		return &ast.ShortFuncLit{Body: body}
+		return &ast.ShortFuncLit{And: pos, Body: body}
+	case token.LAND:
+		// && is two short func lits, as in & &x.
+		p.next()
+		body := p.parseExpr()
+		inner := &ast.ShortFuncLit{And: pos + 1, Body: body}
+		return &ast.ShortFuncLit{And: pos, Body: inner}
 	case token.LPAREN:
 		p.next()
-		defer p.want(token.RPAREN)
-		return p.parseExpr()
+		x := p.parseExpr()
+		p.want(token.RPAREN)
+		return x
 	}
-	// TODO(kr): don't crash here
-	p.errorf("error tok = %v want ident or literal", p.tok)
-	return nil
+	p.errorf("expected operand, found %s", tokString(p.tok, p.lit))
+	panic("unreached")
+}
+
+func (p *parser) parseListLit() *ast.ListLit {
+	x := &ast.ListLit{Lbrack: p.want(token.LBRACK)}
+	for p.tok != token.RBRACK {
+		x.Elts = append(x.Elts, p.parseExpr())
+		if p.tok == token.RBRACK {
+			break
+		}
+		p.want(token.COMMA)
+	}
+	x.Rbrack = p.want(token.RBRACK)
+	return x
+}
+
+func (p *parser) parseRecordLit() *ast.RecordLit {
+	x := &ast.RecordLit{Lbrace: p.want(token.LBRACE)}
+	for p.tok != token.RBRACE {
+		f := &ast.Field{Name: p.parseIdent()}
+		f.Colon = p.want(token.COLON)
+		f.Value = p.parseExpr()
+		x.Fields = append(x.Fields, f)
+		if p.tok == token.RBRACE {
+			break
+		}
+		p.want(token.COMMA)
+	}
+	x.Rbrace = p.want(token.RBRACE)
+	return x
+}
+
+func (p *parser) parseMapLit() *ast.MapLit {
+	x := &ast.MapLit{Map: p.want(token.MAP)}
+	x.Lbrace = p.want(token.LBRACE)
+	for p.tok != token.RBRACE {
+		kv := &ast.KeyValue{Key: p.parseExpr()}
+		kv.Colon = p.want(token.COLON)
+		kv.Value = p.parseExpr()
+		x.Elts = append(x.Elts, kv)
+		if p.tok == token.RBRACE {
+			break
+		}
+		p.want(token.COMMA)
+	}
+	x.Rbrace = p.want(token.RBRACE)
+	return x
 }
 
 func (p *parser) parseFuncLit() ast.Expr {
-	p.want(token.FUNC)
-	params := p.parseVarList()
+	pos := p.want(token.FUNC)
+	params, types, ellipsis := p.parseParams()
+	result := p.parseResult()
 	body := p.parseBlockStmt()
-	return &ast.FuncLit{Params: params, Body: body}
+	return &ast.FuncLit{
+		Func:     pos,
+		Params:   params,
+		Types:    types,
+		Ellipsis: ellipsis,
+		Result:   result,
+		Body:     body,
+	}
 }
 
-// errorf prints the current position p.pos followed by
-// a formatted error message.
+// error records a syntax error at pos.
+// It is also the error handler for p.scanner.
+// To keep one mistake from producing a cascade
+// of errors, only the first error on a line is recorded.
+func (p *parser) error(pos token.Position, msg string) {
+	p.adderror(&Error{Pos: pos, Msg: msg})
+}
+
+func (p *parser) adderror(e *Error) {
+	if n := len(p.errors); n > 0 {
+		last := p.errors[n-1].Pos
+		if last.Filename == e.Pos.Filename && last.Line == e.Pos.Line {
+			return
+		}
+	}
+	p.errors = append(p.errors, e)
+}
+
+// errorf records a syntax error at the current position p.pos
+// and abandons the current statement or declaration.
 func (p *parser) errorf(format string, v ...interface{}) {
-	s := p.fileSet.Position(p.pos)
-	v = append([]interface{}{s}, v...)
-	format = strings.TrimSpace(format) + "\n"
-	fmt.Fprintf(os.Stderr, "%s: "+format, v...)
-	os.Exit(1)
+	p.error(p.fileSet.Position(p.pos), fmt.Sprintf(format, v...))
+	panic(bailout{})
+}
+
+// errorExpected records that the parser wanted tok
+// at the current position, and abandons the current
+// statement or declaration.
+func (p *parser) errorExpected(tok token.Token) {
+	p.adderror(&Error{
+		Pos:  p.fileSet.Position(p.pos),
+		Msg:  "expected " + tokString(tok, "") + ", found " + tokString(p.tok, p.lit),
+		Want: tok,
+		Got:  p.tok,
+	})
+	panic(bailout{})
+}
+
+// catch recovers from a bailout, if there is one,
+// and calls sync to skip ahead to a place
+// where the parser can resume.
+func (p *parser) catch(sync func()) {
+	if v := recover(); v != nil {
+		if _, ok := v.(bailout); !ok {
+			panic(v)
+		}
+		sync()
+	}
+}
+
+// syncStmt skips to the end of the current statement:
+// just past the next semicolon, or up to the closing brace
+// of the enclosing block.
+func (p *parser) syncStmt() {
+	depth := 0
+	for p.tok != token.EOF {
+		switch p.tok {
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			if depth == 0 {
+				return
+			}
+			depth--
+		case token.SEMICOLON:
+			if depth == 0 {
+				p.next()
+				return
+			}
+		}
+		p.next()
+	}
+}
+
+// syncDecl skips to the start of the next
+// top-level declaration.
+func (p *parser) syncDecl() {
+	depth := 0
+	for p.tok != token.EOF {
+		switch p.tok {
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			depth--
+		case token.FUNC, token.IMPORT, token.TYPE, token.VAR, token.CONST:
+			if depth <= 0 {
+				return
+			}
+		}
+		p.next()
+	}
+}
+
+// tokString describes tok for use in an error message.
+// Identifiers and literals are described by lit, if given.
+func tokString(tok token.Token, lit string) string {
+	switch {
+	case tok == token.SEMICOLON && lit == "\n":
+		return "newline"
+	case tok == token.EOF:
+		return "EOF"
+	case tok.IsLiteral() && lit != "":
+		return lit
+	}
+	return "'" + tok.String() + "'"
 }
 
 func readSource(name string) ([]byte, error) {
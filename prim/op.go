@@ -1,4 +1,90 @@
 // Primitive operations
+//
+// Integers are exact between -(1<<53 - 1) and 1<<53 - 1,
+// the range in which a JavaScript number holds
+// every integer. Add, Sub, Mul, Quo, Rem, Neg, and ToInt
+// panic with "integer overflow" if the result is
+// an integer outside that range. Quo and Rem truncate
+// toward zero, and panic if the divisor is zero.
+// If either operand is a float, the operation is
+// done in 64-bit floating point instead.
+// ToInt truncates toward zero.
+// Package fun folds constants by the same rules,
+// reporting overflow as a compile-time error.
+//
+// If integers are unbounded (see fun.BigInts),
+// integer operations never overflow, and ToInt
+// panics only for infinities and NaN.
+//
+// Strings are sequences of bytes, usually UTF-8,
+// as in Go. At run time each byte is one character
+// of a JavaScript string. Len, Index, and Slice
+// count bytes, and strings compare bytewise.
+// Add concatenates two strings, and panics
+// if only one operand is a string.
+// RuneStr encodes a rune in UTF-8, giving U+FFFD
+// for an invalid rune; Runes and DecodeRune decode
+// UTF-8 as Go's unicode/utf8 does, taking
+// each invalid byte as U+FFFD.
+// Range lists the key and value pairs that a
+// range loop over a string or a list goes through:
+// the byte index and value of each rune in a string,
+// decoded as by DecodeRune, or the index and value
+// of each element of a list. For a map, see below.
+// Itoa and Atoi convert between integers and
+// their decimal strings; Atoi also yields
+// whether the string was valid.
+//
+// Lists are JavaScript arrays. List makes a list
+// of its arguments, and Append makes a new list of
+// the elements of its first argument followed by
+// the rest, never growing the first in place as
+// Go's append can. So Append copies the whole list,
+// and a loop that grows a list one Append
+// at a time takes time quadratic in its length.
+//
+// Maps are hash maps from keys to values,
+// with keys compared as by Eql. MakeMap takes
+// alternating keys and values. Index, SetIndex,
+// and Len also work on maps; Index panics
+// for a missing key, IndexZero is the same
+// but yields 0 instead, and Lookup yields the
+// value and whether the key was present.
+// Keys lists the keys in the order they
+// were first inserted, and Range lists the
+// keys and their values in the same order.
+// Since it lists them when it is called,
+// a range loop goes through the entries a map
+// had when the loop started.
+//
+// Boxed tests whether a value of a data type
+// is a record, as opposed to a constant;
+// see fun.Conrep.
+//
+// Panic starts panicking with a value of any
+// type, as does any runtime error, with a string
+// value beginning "runtime error: ". Panicking
+// with nil panics with the string "panic called
+// with nil argument" instead, as in Go, so that
+// nil means no panic. The run time then
+// calls the current handler, set by SetHandler
+// and read by Handler, with the value. There is
+// no handler at first; a panic with none prints
+// the value and a trace of the frames that were
+// running, innermost first, and exits nonzero.
+// Repanic continues a panic that has not been
+// recovered. SetDeferID notes the id of the
+// function a deferred call is about to call,
+// and DeferID reads it. Recover, given the id
+// of the function calling it, stops panicking
+// and yields the value, if that function is the
+// one called by a deferred call; otherwise, or
+// if there is no panic, it yields nil,
+// which prints as "nil".
+// Enter pushes the name of a function on the
+// frames in a trace, yielding their previous
+// number, and Leave pops them back to a number.
+// See package fun for how they are used.
 package prim
 
 import "log"
@@ -15,50 +101,248 @@ const (
 	Sub
 	Mul
 	Quo
+	Rem
+	Neg
 	Lt
+	Leq
+	Gt
+	Geq
+	Eql
 	Ineq
 	Callcc
+	Makeref
+	Deref
+	Assign
+	List
+	Index
+	IndexZero
+	SetIndex
+	Len
+	Append
+	Field
+	CheckArgs
+	Rest
+	ToInt
+	ToFloat
+	Slice
+	Itoa
+	Atoi
+	RuneStr
+	Runes
+	DecodeRune
+	Range
+	MakeMap
+	Lookup
+	Delete
+	Keys
+	Boxed
+	Enter
+	Leave
+	Handler
+	SetHandler
+	Panic
+	Repanic
+	Recover
+	DeferID
+	SetDeferID
 )
 
 var opNames = [...]string{
-	invalid: "invalid",
-	Println: "Println",
-	Add:     "Add",
-	Sub:     "Sub",
-	Mul:     "Mul",
-	Quo:     "Quo",
-	Lt:      "Lt",
-	Ineq:    "Ineq",
-	Callcc:  "Callcc",
+	invalid:    "invalid",
+	Println:    "Println",
+	Add:        "Add",
+	Sub:        "Sub",
+	Mul:        "Mul",
+	Quo:        "Quo",
+	Rem:        "Rem",
+	Neg:        "Neg",
+	Lt:         "Lt",
+	Leq:        "Leq",
+	Gt:         "Gt",
+	Geq:        "Geq",
+	Eql:        "Eql",
+	Ineq:       "Ineq",
+	Callcc:     "Callcc",
+	Makeref:    "Makeref",
+	Deref:      "Deref",
+	Assign:     "Assign",
+	List:       "List",
+	Index:      "Index",
+	IndexZero:  "IndexZero",
+	SetIndex:   "SetIndex",
+	Len:        "Len",
+	Append:     "Append",
+	Field:      "Field",
+	CheckArgs:  "CheckArgs",
+	Rest:       "Rest",
+	ToInt:      "ToInt",
+	ToFloat:    "ToFloat",
+	Slice:      "Slice",
+	Itoa:       "Itoa",
+	Atoi:       "Atoi",
+	RuneStr:    "RuneStr",
+	Runes:      "Runes",
+	DecodeRune: "DecodeRune",
+	Range:      "Range",
+	MakeMap:    "MakeMap",
+	Lookup:     "Lookup",
+	Delete:     "Delete",
+	Keys:       "Keys",
+	Boxed:      "Boxed",
+	Enter:      "Enter",
+	Leave:      "Leave",
+	Handler:    "Handler",
+	SetHandler: "SetHandler",
+	Panic:      "Panic",
+	Repanic:    "Repanic",
+	Recover:    "Recover",
+	DeferID:    "DeferID",
+	SetDeferID: "SetDeferID",
 }
 
+// An NArg of -1 means the operation is variadic.
 var opNArg = [...]int{
-	invalid: -1,
-	Println: 1,
-	Add:     2,
-	Sub:     2,
-	Mul:     2,
-	Quo:     2,
-	Lt:      2,
-	Ineq:    2,
-	Callcc:  -1, // unused; special case in ../cps/conf.go
+	invalid:    -1,
+	Println:    -1,
+	Add:        2,
+	Sub:        2,
+	Mul:        2,
+	Quo:        2,
+	Rem:        2,
+	Neg:        1,
+	Lt:         2,
+	Leq:        2,
+	Gt:         2,
+	Geq:        2,
+	Eql:        2,
+	Ineq:       2,
+	Callcc:     -1, // unused; special case in ../cps/conf.go
+	Makeref:    1,
+	Deref:      1,
+	Assign:     2,
+	List:       -1,
+	Index:      2,
+	IndexZero:  2,
+	SetIndex:   3,
+	Len:        1,
+	Append:     -1,
+	Field:      2,
+	CheckArgs:  2,
+	Rest:       2,
+	ToInt:      1,
+	ToFloat:    1,
+	Slice:      3,
+	Itoa:       1,
+	Atoi:       1,
+	RuneStr:    1,
+	Runes:      1,
+	DecodeRune: 2,
+	Range:      1,
+	MakeMap:    -1,
+	Lookup:     2,
+	Delete:     2,
+	Keys:       1,
+	Boxed:      1,
+	Enter:      1,
+	Leave:      1,
+	Handler:    0,
+	SetHandler: 1,
+	Panic:      1,
+	Repanic:    0,
+	Recover:    1,
+	DeferID:    0,
+	SetDeferID: 1,
 }
 
 var opNRes = [...]int{
-	invalid: -1,
-	Println: 0,
-	Add:     1,
-	Sub:     1,
-	Mul:     1,
-	Quo:     1,
-	Lt:      0,
-	Ineq:    0,
-	Callcc:  -1, // unused; special case in ../cps/conf.go
+	invalid:    -1,
+	Println:    0,
+	Add:        1,
+	Sub:        1,
+	Mul:        1,
+	Quo:        1,
+	Rem:        1,
+	Neg:        1,
+	Lt:         0,
+	Leq:        0,
+	Gt:         0,
+	Geq:        0,
+	Eql:        0,
+	Ineq:       0,
+	Callcc:     -1, // unused; special case in ../cps/conf.go
+	Makeref:    1,
+	Deref:      1,
+	Assign:     0,
+	List:       1,
+	Index:      1,
+	IndexZero:  1,
+	SetIndex:   0,
+	Len:        1,
+	Append:     1,
+	Field:      1,
+	CheckArgs:  0,
+	Rest:       1,
+	ToInt:      1,
+	ToFloat:    1,
+	Slice:      1,
+	Itoa:       1,
+	Atoi:       1,
+	RuneStr:    1,
+	Runes:      1,
+	DecodeRune: 1,
+	Range:      1,
+	MakeMap:    1,
+	Lookup:     1,
+	Delete:     0,
+	Keys:       1,
+	Boxed:      0,
+	Enter:      1,
+	Leave:      0,
+	Handler:    1,
+	SetHandler: 0,
+	Panic:      0,
+	Repanic:    0,
+	Recover:    1,
+	DeferID:    1,
+	SetDeferID: 0,
 }
 
+// Pure operations have no effects, so one whose
+// result is unused may be removed. None of them
+// may panic, since a panic is an effect.
 var opPure = [...]bool{
-	Lt:   true,
-	Ineq: true,
+	Lt:      true,
+	Leq:     true,
+	Gt:      true,
+	Geq:     true,
+	Eql:     true,
+	Ineq:    true,
+	Makeref: true,
+	Deref:   true,
+	List:    true,
+	Len:     true,
+	Append:  true,
+	ToFloat: true,
+	Itoa:    true,
+	Atoi:    true,
+	RuneStr: true,
+	Runes:   true,
+	MakeMap: true,
+	Boxed:   true,
+}
+
+// Branch operations yield no results;
+// instead they proceed with the first of
+// two continuations if the test is true
+// and the second otherwise.
+var opBranch = [...]bool{
+	Lt:    true,
+	Leq:   true,
+	Gt:    true,
+	Geq:   true,
+	Eql:   true,
+	Ineq:  true,
+	Boxed: true,
 }
 
 func (o Op) String() string {
@@ -85,6 +369,14 @@ func (o Op) NRes() int {
 	return opNRes[o]
 }
 
+// Branch returns whether o is a branch operation.
+func (o Op) Branch() bool {
+	if o == invalid {
+		log.Fatal("invalid op")
+	}
+	return int(o) < len(opBranch) && opBranch[o]
+}
+
 // Pure returns whether o has no side effects.
 func (o Op) Pure() bool {
 	if o == invalid {
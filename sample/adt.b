@@ -0,0 +1,108 @@
+package main
+
+type Shape {
+	Circle(r)
+	Rect(w, h)
+	Square(s)
+	Point
+}
+
+type Tree {
+	Leaf
+	Node(l, v, r)
+}
+
+func area(s) {
+	switch s {
+	case Circle(r):
+		return 3 * r * r
+	case Rect(w, h):
+		return w * h
+	case Square(x):
+		return x * x
+	}
+	return 0
+}
+
+func insert(t, x) {
+	switch t {
+	case Leaf:
+		return Node(Leaf, x, Leaf)
+	case Node(l, v, r):
+		if x < v {
+			return Node(insert(l, x), v, r)
+		}
+		return Node(l, v, insert(r, x))
+	}
+	return t
+}
+
+func walk(t, f) {
+	switch t {
+	case Node(l, v, r):
+		walk(l, f)
+		f(v)
+		walk(r, f)
+	}
+	return 0
+}
+
+func kind(s) {
+	switch s {
+	case Circle(_), Square(_):
+		return "round or square"
+	case Point:
+		return "point"
+	default:
+		return "other"
+	}
+	return ""
+}
+
+func main() {
+	println(area(Circle(2)), area(Rect(3, 4)), area(Square(5)), area(Point))
+	shapes := [Circle(1), Rect(1, 2), Point]
+	mk := Square
+	shapes = append(shapes, mk(3))
+	for i := 0; i < len(shapes); i++ {
+		println(kind(shapes[i]))
+	}
+
+	t := Leaf
+	xs := [5, 0, 14, -3, 9, 4]
+	for i := 0; i < len(xs); i++ {
+		t = insert(t, xs[i])
+	}
+	walk(t, func(v) {
+		println(v)
+	})
+
+	for i := 0; i < 3; i++ {
+		switch Rect(i, 0) {
+		case Rect(w, _):
+			w = w * 10
+			if i == 1 {
+				break
+			}
+			println("rect", w)
+			continue
+		}
+		println("after", i)
+	}
+}
+
+// Output:
+// 12 12 25 0
+// round or square
+// other
+// point
+// round or square
+// -3
+// 0
+// 4
+// 5
+// 9
+// 14
+// rect 0
+// after 1
+// rect 20
@@ -0,0 +1,39 @@
+package main
+
+type T {
+	A(x, x)
+	B
+}
+
+type U {
+	C
+	B
+}
+
+func main() {
+	t := A(1)
+	switch t {
+	case A(x):
+	case B():
+	case C:
+	case A(y, z), B:
+	case len:
+	}
+	println(T)
+	continue
+}
+
+// Error:
+// 4:7: x redeclared in this block
+// 10:2: B redeclared in this block
+// 14:7: wrong number of arguments to A: have 1, want 2
+// 16:7: wrong number of fields in pattern A: have 1, want 2
+// 17:7: constructor B has no fields
+// 18:7: C is a constructor of U, not T
+// 19:7: duplicate case A in switch
+// 19:9: cannot bind y in case with multiple patterns
+// 19:12: cannot bind z in case with multiple patterns
+// 19:16: duplicate case B in switch
+// 20:7: len is not a constructor
+// 22:10: T (type) is not an expression
+// 23:2: continue is not in a loop
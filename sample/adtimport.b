@@ -0,0 +1,21 @@
+package main
+
+import "test"
+
+func get(o, def) {
+	switch o {
+	case test.Some(x):
+		return x
+	}
+	return def
+}
+
+func main() {
+	println(get(test.Some(1), 2), get(test.None, 3))
+	some := test.Some
+	println(get(some(4), 5))
+}
+
+// Output:
+// 1 3
+// 4
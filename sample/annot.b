@@ -0,0 +1,50 @@
+package main
+
+import "test"
+
+func add(x int, y int) int {
+	return x + y
+}
+
+func sum(xs ...int) int {
+	n := 0
+	for i := 0; i < len(xs); i++ {
+		n = n + xs[i]
+	}
+	return n
+}
+
+func name(p {name: string, ...}) string {
+	return p.name
+}
+
+func apply(f func(int) int, x int) int {
+	return f(x)
+}
+
+func get(o test.Option, def _) _ {
+	switch o {
+	case test.Some(x):
+		return x
+	}
+	return def
+}
+
+func divmod(a int, b int) (int, int) {
+	return a / b, a % b
+}
+
+func main() {
+	println(add(1, 2), sum(1, 2, 3), name({name: "ann", age: 3}))
+	println(apply(&x * 2, 21), get(test.Some("s"), "t"), test.Twice("ab"))
+	q, r := divmod(7, 2)
+	inc := func(x int) int {
+		return x + 1
+	}
+	println(q, r, inc(1))
+}
+
+// Output:
+// 3 6 ann
+// 42 s abab
+// 3 1 2
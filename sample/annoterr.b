@@ -0,0 +1,37 @@
+package main
+
+import "test"
+
+func add(x int, y int) int {
+	return x + y
+}
+
+func f() {
+	return add(1, "2")
+}
+
+func g(s string) int {
+	return s
+}
+
+func h(x float) {
+	return test.Twice(x)
+}
+
+func k(m map[string]int) {
+	m[1] = 2
+}
+
+func main() {
+	f := func(x []int) {
+		return x[0]
+	}
+	f("abc")
+}
+
+// Error:
+// 10:16: cannot use string as int in argument
+// 14:9: cannot use string as int in return statement
+// 18:20: cannot use float as string in argument
+// 22:4: cannot use number as string in index
+// 29:4: cannot use string as []int in argument
@@ -0,0 +1,27 @@
+package main
+
+import "test"
+
+func f(x integer) {
+}
+
+func g(x main) {
+}
+
+func h(x test.F) (int, test.Option) {
+	return 1, test.None
+}
+
+func k(p {a: int, a: int}) {
+}
+
+func main() {
+	println(test.Option)
+}
+
+// Error:
+// 5:10: undefined: integer
+// 8:10: main is not a type
+// 11:10: test.F is not a type
+// 15:19: duplicate field name a in record type
+// 19:10: test.Option (type) is not an expression
@@ -0,0 +1,38 @@
+package main
+
+func add(x, y int) int {
+	return x + y
+}
+
+func first(xs []int) int {
+	return xs[0]
+}
+
+func sum(xs ...int) int {
+	n := 0
+	for i := 0; i < len(xs); i++ {
+		n = n + xs[i]
+	}
+	return n
+}
+
+func apply(f func(...int) int) int {
+	return f(1, 2, 3)
+}
+
+func pair(f func(int, int) int) int {
+	return f(1, 2)
+}
+
+func main() {
+	println(apply(sum), pair(sum))
+	println(apply(add))
+}
+
+func g() {
+	println(apply(first))
+}
+
+// Error:
+// 29:16: cannot use func(int, int) int as func(...int) int in argument
+// 33:16: cannot use func([]int) int as func(...int) int in argument
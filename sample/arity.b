@@ -0,0 +1,13 @@
+package main
+
+func f(x, y) {
+	println(y)
+}
+
+func main() {
+	g := f
+	g(1)
+}
+
+// Error:
+// 9:2: wrong number of arguments to g: have 1, want 2
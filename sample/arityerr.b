@@ -0,0 +1,22 @@
+package main
+
+import "test"
+
+func f(x, y) {
+}
+
+func g(x, ys...) {
+}
+
+func main() {
+	f(1, 2, 3)
+	g()
+	test.F(1)
+	test.Some()
+}
+
+// Error:
+// 12:2: wrong number of arguments to f: have 3, want 2
+// 13:2: wrong number of arguments to g: have 0, want at least 1
+// 14:2: wrong number of arguments to F: have 1, want 0
+// 15:2: wrong number of arguments to Some: have 0, want 1
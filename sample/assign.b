@@ -0,0 +1,40 @@
+package main
+
+func counter() {
+	n := 0
+	return func() {
+		n = n + 1
+		return n
+	}
+}
+
+func inc(x) {
+	x = x + 1
+	return x
+}
+
+func main() {
+	a := 1
+	b := a + 1
+	a = b * 10
+	println(a, b)
+
+	c := counter()
+	c()
+	c()
+	println(c())
+
+	x := 1
+	if 1 {
+		x := x + 1
+		println(x)
+		x = 5
+	}
+	println(x, inc(x))
+}
+
+// Output:
+// 20 2
+// 3
+// 2
+// 1 2
@@ -0,0 +1,20 @@
+package main
+
+func f() {
+}
+
+func main() {
+	a := 1
+	a := 2
+	b = 3
+	f = 4
+	println = 5
+	1 := 6
+}
+
+// Error:
+// 8:2: no new variables on left side of :=
+// 9:2: undefined: b
+// 10:2: cannot assign to f
+// 11:2: cannot assign to println
+// 12:2: non-name on left side of :=
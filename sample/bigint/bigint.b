@@ -0,0 +1,29 @@
+package main
+
+const (
+	huge  = 123456789012345678901234567890
+	small = huge - huge + 1
+)
+
+func fact(n) {
+	if n == 0 {
+		return 1
+	}
+	return n * fact(n-1)
+}
+
+func main() {
+	n := 9007199254740991
+	println(n+1, n+2, -n-2, (n+2)-(n+1))
+	println(fact(25), fact(25)/fact(23), fact(25)%7)
+	println(huge, -huge/7, huge%1000, small)
+	println([n + 1, 2], n+1 > n, n+1 == 9007199254740992, float(n+2))
+	println(int(1e20), int(-1.5e300) < 0, 2*n/2 == n)
+}
+
+// Output:
+// 9007199254740992 9007199254740993 -9007199254740993 1
+// 15511210043330985984000000 600 0
+// 123456789012345678901234567890 -17636684144620811271604938270 890 1
+// [ 9007199254740992, 2 ] 1 1 9007199254740992.0
+// 100000000000000000000 1 1
@@ -0,0 +1,22 @@
+package main
+
+func f(n) {
+	switch n {
+	case 9007199254740993:
+		return "big"
+	case -9007199254740993, 9007199254740994:
+		return "other big"
+	case 1:
+		return "one"
+	}
+	return "none"
+}
+
+func main() {
+	println(f(9007199254740993), f(-9007199254740993), f(9007199254740994))
+	println(f(9007199254740992), f(1), f(2))
+}
+
+// Output:
+// big other big other big
+// none one none
@@ -0,0 +1,18 @@
+package main
+
+func apply(f, x) {
+	return f(x)
+}
+
+func main() {
+	xs := [1, 2]
+	println(apply(len, xs))
+	f := append
+	g := recover
+	println(true, f, g)
+}
+
+// Error:
+// 9:16: len (built-in function len) must be called
+// 10:7: append (built-in function append) must be called
+// 11:7: recover (built-in function recover) must be called
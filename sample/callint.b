@@ -3,3 +3,6 @@ package main
 func main() {
 	1("hello")
 }
+
+// Error:
+// 4:2: invalid operation: cannot call non-function of type number
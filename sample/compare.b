@@ -0,0 +1,38 @@
+package main
+
+func max(a, b) {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func show(b) {
+	if b {
+		println("yes")
+	} else {
+		println("no")
+	}
+}
+
+func main() {
+	println(1 < 2, 2 < 1, 1 <= 1, 2 >= 3, 3 > 2, 1 == 1, 1 != 1)
+	println(1+2 == 3, 2*3 < 5)
+	x := 3 > 2
+	println(x)
+	show(x)
+	show(1 == 2)
+	println(max(3, 7), max(9, 2))
+	if "a" == "a" {
+		println("eq")
+	}
+}
+
+// Output:
+// 1 0 1 0 1 1 0
+// 1 0
+// 1
+// yes
+// no
+// 7 9
+// eq
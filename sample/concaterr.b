@@ -0,0 +1,9 @@
+package main
+
+func main() {
+	n := 5
+	println("n = " + n)
+}
+
+// Error:
+// 5:17: invalid operation: mismatched types string and number
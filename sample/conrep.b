@@ -0,0 +1,86 @@
+package main
+
+import "test"
+
+type Color {
+	Red
+	Green
+	Blue
+}
+
+type Tree {
+	Leaf
+	Node(l, v, r)
+}
+
+type Tuple {
+	Pair(a, b)
+}
+
+type Expr {
+	Zero
+	One
+	Neg(x)
+	Add(x, y)
+}
+
+func name(c) {
+	switch c {
+	case Red:
+		return "red"
+	case Blue:
+		return "blue"
+	}
+	return "other"
+}
+
+func size(t) {
+	switch t {
+	case Node(l, _, r):
+		return size(l) + 1 + size(r)
+	}
+	return 0
+}
+
+func swap(p) {
+	switch p {
+	case Pair(a, b):
+		return Pair(b, a)
+	}
+	return p
+}
+
+func eval(e) {
+	switch e {
+	case One:
+		return 1
+	case Neg(x):
+		return -eval(x)
+	case Add(x, y):
+		return eval(x) + eval(y)
+	}
+	return 0
+}
+
+func get(o) {
+	switch o {
+	case test.Some(x):
+		return x
+	}
+	return -1
+}
+
+func main() {
+	println(name(Red), name(Green), name(Blue), Blue == Blue, Red == Blue)
+	println(size(Node(Node(Leaf, 1, Leaf), 2, Leaf)), Node(Leaf, 3, Leaf))
+	println(swap(Pair(1, 2)))
+	println(eval(Add(One, Neg(Add(One, One)))), eval(Zero), Add(One, Neg(Zero)))
+	println(get(test.Some(5)), get(test.None), test.Some(5), test.None)
+}
+
+// Output:
+// red other blue 1 0
+// 2 [ 0, 3, 0 ]
+// [ 2, 1 ]
+// -1 0 [ 1, 1, [ 0, 0 ] ]
+// 5 -1 [ 5 ] 0
@@ -0,0 +1,38 @@
+package main
+
+import "test"
+
+const greeting = "hello, " + name
+
+const name = "const"
+
+const (
+	a = iota
+	b
+	c
+	_
+	e
+)
+
+const (
+	x, y = iota * 10, -iota
+	z, w
+)
+
+const (
+	big   = test.Max * 7 / 2
+	small = !(big > 30) || false
+)
+
+func main() {
+	println(greeting)
+	println(a, b, c, e)
+	println(x, y, z, w)
+	println(big, small)
+}
+
+// Output:
+// hello, const
+// 0 1 2 4
+// 0 0 10 -1
+// 35 0
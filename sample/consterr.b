@@ -0,0 +1,31 @@
+package main
+
+func f() {
+	return 1
+}
+
+var v = 1
+
+const (
+	a = f()
+	b = v + 1
+	c, d = 1
+	e = 1, 2
+)
+
+var x, y = 1, 2, 3
+
+var z = iota
+
+func main() {
+	a = 2
+}
+
+// Error:
+// 10:6: const initializer for a is not a constant
+// 11:6: const initializer for b is not a constant
+// 12:5: missing init expr for const declaration
+// 13:9: extra init expr
+// 16:5: assignment mismatch: 2 variables but 3 values
+// 18:9: undefined: iota
+// 21:2: cannot assign to a
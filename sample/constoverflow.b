@@ -0,0 +1,14 @@
+package main
+
+const (
+	max = 9007199254740991
+	c   = 1.0 / 0
+	a   = max*2 + 1
+)
+
+func main() {
+	println(a, c)
+}
+
+// Error:
+// 6:8: constant overflow
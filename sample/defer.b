@@ -0,0 +1,91 @@
+package main
+
+func trace(s) {
+	println("enter", s)
+	return s
+}
+
+func un(s) {
+	println("leave", s)
+}
+
+func order() {
+	defer un(trace("a"))
+	for i := 0; i < 3; i++ {
+		defer println("deferred", i)
+	}
+	println("in order")
+}
+
+func early(n) {
+	defer println("early done")
+	if n > 0 {
+		return n
+	}
+	println("not early")
+	return 0
+}
+
+func safediv(a, b) {
+	defer func() {
+		e := recover()
+		if e != nil {
+			println("recovered:", e)
+		}
+	}()
+	return a / b
+}
+
+func raise(s) {
+	defer println("raise unwinding")
+	panic(s)
+	println("not reached")
+}
+
+func catch() {
+	defer func() {
+		println("caught", recover())
+	}()
+	raise("boom")
+	return 1
+}
+
+func nested() {
+	defer func() {
+		println("outer", recover())
+	}()
+	defer func() {
+		panic("second")
+	}()
+	panic("first")
+}
+
+func main() {
+	order()
+	println(early(1), early(0))
+	println(safediv(7, 2))
+	println(safediv(1, 0))
+	println(catch())
+	nested()
+	println("no panic:", recover())
+}
+
+// Output:
+// enter a
+// in order
+// deferred 2
+// deferred 1
+// deferred 0
+// leave a
+// early done
+// not early
+// early done
+// 1 0
+// 3
+// recovered: runtime error: integer divide by zero
+// 0
+// raise unwinding
+// caught boom
+// 0
+// outer second
+// no panic: nil
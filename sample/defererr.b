@@ -0,0 +1,22 @@
+package main
+
+type Sizer interface {
+	Size() int
+}
+
+type Box {
+	NewBox(n)
+}
+
+func (b Box) Size() int {
+	return 1
+}
+
+func main() {
+	defer Sizer(NewBox(1))
+	defer undefined()
+}
+
+// Error:
+// 16:8: defer requires function call, not conversion
+// 17:8: undefined: undefined
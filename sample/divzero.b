@@ -0,0 +1,13 @@
+package main
+
+func div(a, b) {
+	return a / b
+}
+
+func main() {
+	println(div(1.0, 0), div(-1, 0.0))
+	println(div(1, 0))
+}
+
+// Panic:
+// runtime error: integer divide by zero
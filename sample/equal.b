@@ -0,0 +1,33 @@
+package main
+
+type Opt {
+	None
+	Some(x)
+}
+
+type Pair {
+	P(a, b)
+}
+
+func say(s) {
+	println(s)
+	return s
+}
+
+func main() {
+	println(Some(1) == Some(1), Some(1) == Some(2), Some(1) != None, None == None)
+	println(P("a", 1.5) == P("a", 1.5), P("a", 1) == P("b", 1))
+	println({a: 1, b: "x"} == {b: "x", a: 1}, {a: 1} != {a: 2})
+	println({p: Some(2), q: P("c", 0.5)} == {q: P("c", 0.5), p: Some(2)})
+	r := {b: say("b"), a: say("a")}
+	println(r.a, r.b)
+}
+
+// Output:
+// 1 0 1 1
+// 1 0
+// 1 1
+// 1
+// b
+// a
+// a b
@@ -0,0 +1,18 @@
+package main
+
+func same(x, y) {
+	return x == y
+}
+
+func main() {
+	println([1] == [1])
+	m := map{}
+	println(m != m)
+	println({f: main} == {f: main})
+	println(same(1, 1))
+}
+
+// Error:
+// 8:14: invalid operation: operator == not defined on []int
+// 10:12: invalid operation: operator != not defined on map[a]b
+// 11:20: invalid operation: operator == not defined on {f: func() a}
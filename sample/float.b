@@ -0,0 +1,33 @@
+package main
+
+const (
+	half  = 1 / 2.0
+	seven = 7
+	big   = 9007199254740991
+)
+
+func main() {
+	n := 7
+	println(7/2, n/2, -7/2, -n/2)
+	println(7%3, n%3, -7%3, -n%3)
+	println(7.0/2, float(n)/2, 1.5+half, 2.5*2)
+	println(int(3.9), int(-3.9), int(float(n)/2), seven/2)
+	println(1 < 1.5, 2.0 == 2, n == 7.0, 3 != 3.0)
+	println(5.5%2, 1e3, 0.1+0.2, half)
+	x := 1.0
+	for i := 0; i < 3; i++ {
+		x = x * 10
+	}
+	println(x, [1.5, 2, float(3)])
+	println(big, -big, big-1)
+}
+
+// Output:
+// 3 3 -3 -3
+// 1 1 -1 -1
+// 3.5 3.5 2.0 5.0
+// 3 -3 3 3
+// 1 1 1 0
+// 1.5 1000.0 0.30000000000000004 0.5
+// 1000.0 [ 1.5, 2.0, 3.0 ]
+// 9007199254740991 -9007199254740991 9007199254740990
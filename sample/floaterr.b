@@ -0,0 +1,9 @@
+package main
+
+func main() {
+	println(9007199254740991, -9007199254740991)
+	println(9007199254740992)
+}
+
+// Error:
+// 5:10: constant 9007199254740992 overflows int
@@ -0,0 +1,35 @@
+package main
+
+func name(x) {
+	switch x {
+	case 1:
+		return "one"
+	case 2:
+		return "two"
+	case 3:
+		return "three"
+	}
+	return "other"
+}
+
+func main() {
+	n := 7
+	n = 2.0
+	println(n / 4)
+	println([10, 20][1.0], name(2.0))
+	f := 1.5
+	f = 2
+	println(f / 4)
+	fs := [0.5]
+	fs = append(fs, 3)
+	println(fs[1] / 2)
+	x := 2.0
+	println(x / 4)
+}
+
+// Output:
+// 0
+// 20 two
+// 0.5
+// 1.5
+// 0.5
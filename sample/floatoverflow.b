@@ -0,0 +1,9 @@
+package main
+
+func main() {
+	x := 2.5e400
+	println(x)
+}
+
+// Error:
+// 4:7: constant 2.5e400 overflows float
@@ -0,0 +1,73 @@
+package main
+
+func main() {
+	for i := 0; i < 3; i++ {
+		println(i)
+	}
+
+	n := 0
+	for n < 3 {
+		n = n + 1
+	}
+	println(n)
+
+	k := 0
+	for {
+		k++
+		if k == 5 {
+			break
+		}
+	}
+	println(k)
+
+	for i := 0; i < 5; i++ {
+		if i == 1 || i == 3 {
+			continue
+		}
+		println(i)
+	}
+
+outer:
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			if j == 2 {
+				continue outer
+			}
+			if i == 2 {
+				break outer
+			}
+			println(i, j)
+		}
+	}
+
+	fs := 0
+	for i := 0; i < 3; i++ {
+		f := func() {
+			return i
+		}
+		fs = fs + f()
+	}
+	println(fs)
+
+	sum := 0
+	for i := 1; i <= 100000; i++ {
+		sum = sum + i
+	}
+	println(sum)
+}
+
+// Output:
+// 0
+// 1
+// 2
+// 3
+// 5
+// 0
+// 2
+// 4
+// 0 0
+// 0 1
+// 1 0
+// 1 1
+// 3
+// 5000050000
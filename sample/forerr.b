@@ -0,0 +1,24 @@
+package main
+
+func main() {
+	break
+L:
+	for {
+		continue M
+	}
+	for i := 0; i < 1; j := 1 {
+		func() {
+			break
+		}()
+	}
+M:
+	println(1)
+}
+
+// Error:
+// 4:2: break is not in a loop
+// 5:1: label L defined and not used
+// 7:12: invalid continue label M
+// 9:21: cannot declare in post statement of for loop
+// 11:4: break is not in a loop
+// 14:1: label M defined and not used
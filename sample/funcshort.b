@@ -1,10 +1,16 @@
 package main
 
+func apply(f, x, y) {
+	return f(x, y)
+}
+
 func main() {
 	println((&5)())
 	println((&x)(5))
 	println((&y)(4, 5))
 	println((&z)(3, 4, 5))
+	println(apply(&x+y, 2, 3), apply(&y, 4, 5))
+	println((&(&x+y)(x, 1))(4))
 }
 
 // Output:
@@ -12,3 +18,5 @@ func main() {
 // 5
 // 5
 // 5
+// 5 5
+// 5
@@ -0,0 +1,10 @@
+package main
+
+import "cyclea"
+
+func main() {
+	cyclea.F()
+}
+
+// Error:
+// src/cycleb/b.b:3:8: import cycle not allowed: "cyclea" imports "cycleb" imports "cyclea"
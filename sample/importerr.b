@@ -0,0 +1,19 @@
+package main
+
+import (
+	"greet"
+	"greet"
+	. "test"
+)
+
+func F() {
+}
+
+func main() {
+	greet.Hello("x")
+}
+
+// Error:
+// 5:2: "greet" imported more than once
+// 6:2: "test" imported and not used
+// 6:2: F already declared through dot-import of package test
@@ -0,0 +1,10 @@
+package main
+
+import "nosuchpackage"
+
+func main() {
+	nosuchpackage.F()
+}
+
+// Error:
+// 3:8: package not found: nosuchpackage
@@ -0,0 +1,29 @@
+package main
+
+import (
+	_ "greet"
+	. "test"
+	t "test"
+)
+
+func get(o) {
+	switch o {
+	case Some(x):
+		return x
+	case None:
+		return 0
+	}
+	return -1
+}
+
+func main() {
+	F()
+	t.F()
+	println(Max, Count, get(Some(7)), get(t.None))
+}
+
+// Output:
+// greet init
+// hello, test
+// hello, test
+// 10 20 7 0
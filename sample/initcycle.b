@@ -0,0 +1,16 @@
+package main
+
+var a = b + 1
+
+var b = f()
+
+func f() {
+	return a
+}
+
+func main() {
+	println(a, b)
+}
+
+// Error:
+// 3:5: initialization cycle for a
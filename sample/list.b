@@ -0,0 +1,30 @@
+package main
+
+func mapf(f, a) {
+	b := []
+	for i := 0; i < len(a); i++ {
+		b = append(b, f(a[i]))
+	}
+	return b
+}
+
+func main() {
+	a := [1, 2, 3, 4]
+	b := mapf(&x*x, a)
+	println(b)
+	println(len(b), b[3])
+	b[0] = 100
+	b[1]++
+	println(b[0], b[1], len([]))
+	c := append(a, 5, 6)
+	println(len(a), len(c))
+	println([[1, 2], [3]][1][0])
+	println()
+}
+
+// Output:
+// [ 1, 4, 9, 16 ]
+// 4 16
+// 100 5 0
+// 4 6
+// 3
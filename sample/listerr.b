@@ -0,0 +1,9 @@
+package main
+
+func main() {
+	a := [1, 2]
+	println(len(a, 1))
+}
+
+// Error:
+// 5:10: wrong number of arguments to len: have 2, want 1
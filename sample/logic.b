@@ -0,0 +1,43 @@
+package main
+
+func t(s) {
+	println(s)
+	return 1
+}
+
+func f(s) {
+	println(s)
+	return 0
+}
+
+func main() {
+	if f("a") && t("b") {
+		println("no")
+	} else {
+		println("ok1")
+	}
+	if t("c") || f("d") {
+		println("ok2")
+	}
+	if !(f("e") || f("f")) && t("g") {
+		println("ok3")
+	}
+	println(!(1 < 2), !0, -5, -(2 + 3), 3 - -2)
+	x := 1 < 2 && 2 < 3 || 0
+	y := 0 || 1 > 2
+	println(x, y)
+	println((&&2)()())
+}
+
+// Output:
+// a
+// ok1
+// c
+// ok2
+// e
+// f
+// g
+// ok3
+// 0 1 -5 -5 5
+// 1 0
+// 2
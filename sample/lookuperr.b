@@ -0,0 +1,10 @@
+package main
+
+func main() {
+	a := [1, 2]
+	v, ok := a[0]
+	println(v, ok)
+}
+
+// Error:
+// 5:2: assignment mismatch: 2 variables but 1 value
@@ -3,3 +3,6 @@ package main
 func main() {
 	(&x(x))(&x(x))
 }
+
+// Error:
+// 4:4: cannot use a as func(a) b: infinite type
@@ -0,0 +1,14 @@
+package main
+
+func main() {
+	m := map{}
+	m[[1]] = 1
+	m[[1]] = 2
+	println(len(m))
+	f := map{main: 1}
+	println(f)
+}
+
+// Error:
+// 4:7: invalid map key type []int
+// 8:7: invalid map key type func() a
@@ -0,0 +1,21 @@
+package main
+
+type Opt {
+	None
+	Some(x)
+}
+
+func main() {
+	m := map{}
+	m[{a: 1, b: "x"}] = 1
+	m[{b: "x", a: 1}] = 2
+	m[{a: 1, b: "y"}] = 3
+	println(len(m), m[{a: 1, b: "x"}])
+	o := map{Some(1): "one", None: "none"}
+	o[Some(1.0)] = "uno"
+	println(len(o), o[Some(1)], o[None])
+}
+
+// Output:
+// 2 2
+// 2 uno none
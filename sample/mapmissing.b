@@ -0,0 +1,12 @@
+package main
+
+func main() {
+	m := map{1: [1, 2]}
+	println(len(m[1]))
+	_, ok := m[2]
+	println(ok)
+	println(len(m[2]))
+}
+
+// Panic:
+// runtime error: key not found in map
@@ -0,0 +1,36 @@
+package main
+
+func main() {
+	m := map{"b": 1, "a": 2}
+	m["c"] = 3
+	m["b"] = 4
+	for k, v := range m {
+		println(k, v)
+	}
+	n := 0
+	for k := range m {
+		delete(m, k)
+		m[k + k] = n
+		n++
+	}
+	for k, v := range m {
+		println(k, v)
+	}
+	total := 0
+	for _, v := range map{1: 10, 2.5: 20} {
+		total = total + v
+	}
+	println(total)
+	for range map{} {
+		println("never")
+	}
+}
+
+// Output:
+// b 4
+// a 2
+// c 3
+// bb 0
+// aa 1
+// cc 2
+// 30
@@ -0,0 +1,45 @@
+package main
+
+func count(words) {
+	m := map{}
+	for i := 0; i < len(words); i++ {
+		m[words[i]]++
+	}
+	return m
+}
+
+func main() {
+	m := count(["b", "a", "b", "c", "a", "b"])
+	k := keys(m)
+	for i := 0; i < len(k); i++ {
+		println(k[i], m[k[i]])
+	}
+	println(len(m), m["c"] - 1)
+	n, ok := m["a"]
+	z, found := m["z"]
+	println(n, ok, z, found)
+	delete(m, "b")
+	delete(m, "nothing")
+	println(m, keys(m))
+	m["b"] = 7
+	println(keys(m))
+
+	f := map{1: "one", 2.5: "two and a half"}
+	println(f[1.0], f[5 / 2.0])
+	f[1.0] = "uno"
+	println(f[1], len(f))
+	_, ok = f[3]
+	println(ok, len(map{}))
+}
+
+// Output:
+// b 3
+// a 2
+// c 1
+// 3 0
+// 2 1 0 0
+// Map(2) { 'a' => 2, 'c' => 1 } [ 'a', 'c' ]
+// [ 'a', 'c', 'b' ]
+// one two and a half
+// uno 2
+// 0 0
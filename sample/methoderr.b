@@ -0,0 +1,52 @@
+package main
+
+type Color {
+	Red
+	Green
+}
+
+func (c Color) String() string {
+	return "red"
+}
+
+type Sizer interface {
+	Size() int
+}
+
+type Namer interface {
+	String() int
+}
+
+func size(s Sizer) {
+	return s.Size()
+}
+
+func f() {
+	return Red.Size()
+}
+
+func g() {
+	return size(Green)
+}
+
+func h() {
+	return Namer(Red)
+}
+
+func i() {
+	return Color(Red)
+}
+
+func j() {
+	return Red.String(1)
+}
+
+func main() {
+}
+
+// Error:
+// 25:13: Color has no field or method Size
+// 29:14: cannot use Color as {Size: func() int} in argument (missing method Size)
+// 33:15: cannot convert Color to {String: func() int} (wrong type for method String)
+// 37:9: cannot convert to non-interface type Color
+// 41:9: wrong number of arguments to String: have 1, want 0
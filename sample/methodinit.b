@@ -0,0 +1,28 @@
+package main
+
+type T {
+	U
+}
+
+func (t T) get() {
+	return b
+}
+
+type Getter interface {
+	get() int
+}
+
+func call(g Getter) int {
+	return g.get()
+}
+
+var a = U.get()
+var c = call(U)
+var b = 5
+
+func main() {
+	println(a, b, c)
+}
+
+// Output:
+// 5 5 5
@@ -0,0 +1,83 @@
+package main
+
+import "test"
+
+type Shape {
+	Circle(r)
+	Rect(w, h)
+}
+
+func (s Shape) Area() {
+	switch s {
+	case Circle(r):
+		return 3 * r * r
+	case Rect(w, h):
+		return w * h
+	}
+	return 0
+}
+
+func (s Shape) String() string {
+	switch s {
+	case Circle(r):
+		return "circle " + itoa(r)
+	}
+	return "rect of area " + itoa(s.Area())
+}
+
+type Color {
+	Red
+	Green
+}
+
+func (c Color) String() string {
+	if c == Red {
+		return "red"
+	}
+	return "green"
+}
+
+func (c Color) Join(sep string, rest ...string) string {
+	s := c.String()
+	for i := 0; i < len(rest); i++ {
+		s = s + sep + rest[i]
+	}
+	return s
+}
+
+type Stringer interface {
+	String() string
+}
+
+func show(s Stringer) string {
+	return "<" + s.String() + ">"
+}
+
+func describe(x) {
+	return x.String()
+}
+
+func main() {
+	c := Circle(2)
+	println(c.Area(), Rect(2, 3).Area(), c.String())
+	println(show(c), show(Red), describe(Green))
+	area := Rect(4, 5).Area
+	join := Red.Join
+	println(area(), join("-", "a", "b"), Green.Join("+"))
+	l := [Stringer(c), Stringer(Green), test.Stringer(Rect(1, 1))]
+	for i := 0; i < len(l); i++ {
+		println(l[i].String())
+	}
+	s := Stringer(Red)
+	s = c
+	println(s.String(), test.Some(3).Or(0), test.None.Or("none"))
+}
+
+// Output:
+// 12 6 circle 2
+// <circle 2> <red> green
+// 20 red-a-b green
+// circle 2
+// green
+// rect of area 1
+// circle 2 3 none
@@ -0,0 +1,52 @@
+package main
+
+type Color {
+	Red
+	Green
+}
+
+type Stringer interface {
+	String() string
+	String() string
+}
+
+type A interface {
+	B() B
+}
+
+type B interface {
+	A() A
+}
+
+type I interface {
+	M() foo
+}
+
+func (x int) M() {
+}
+
+func (s Stringer) M() {
+}
+
+func (c Color) String() string {
+	return "red"
+}
+
+func (c Color) String() string {
+	return d
+}
+
+func main() {
+	Stringer(Red, Green)
+}
+
+// Error:
+// 10:2: duplicate method String
+// 13:6: invalid recursive type A
+// 17:6: invalid recursive type B
+// 22:6: undefined: foo
+// 25:9: invalid receiver type int
+// 28:9: invalid receiver type Stringer
+// 35:16: method Color.String already declared
+// 36:9: undefined: d
+// 40:2: wrong number of arguments in conversion to Stringer: have 2, want 1
@@ -0,0 +1,62 @@
+package main
+
+func divmod(a, b) {
+	q := 0
+	for a >= b {
+		a = a - b
+		q++
+	}
+	return q, a
+}
+
+func swap(x, y) {
+	return y, x
+}
+
+func fib(n) {
+	a, b := 0, 1
+	for i := 0; i < n; i++ {
+		a, b = b, a+b
+	}
+	return a
+}
+
+func main() {
+	q, r := divmod(17, 5)
+	println(q, r)
+	x, y := swap(1, 2)
+	println(x, y)
+	x, z := 3, 4
+	println(x, y, z)
+	_, r = divmod(9, 4)
+	println(r)
+	println(fib(10))
+
+	l := [1, 2, 3]
+	i := 0
+	i, l[i] = 2, 9
+	println(i, l)
+
+	f := func(a) {
+		a, b := a*2, a*3
+		return a + b
+	}
+	println(f(1))
+	for i, j := 0, 3; i < j; i, j = i+1, j-1 {
+		println(i, j)
+	}
+	func() {
+		return
+	}()
+}
+
+// Output:
+// 3 2
+// 2 1
+// 3 1 4
+// 1
+// 55
+// 2 [ 9, 2, 3 ]
+// 5
+// 0 3
+// 1 2
@@ -0,0 +1,17 @@
+package main
+
+func main() {
+	a, b := 1, 2, 3
+	a, b := 3, 4
+	c, c := 1, 2
+	a, 1 = 2, 3
+	d, e := 1
+	println(a, b, c, d, e)
+}
+
+// Error:
+// 4:2: assignment mismatch: 2 variables but 3 values
+// 5:2: no new variables on left side of :=
+// 6:5: c repeated on left side of :=
+// 7:5: cannot assign to expression
+// 8:2: assignment mismatch: 2 variables but 1 value
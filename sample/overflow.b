@@ -0,0 +1,10 @@
+package main
+
+func main() {
+	n := 9007199254740991
+	println(n)
+	println(n + 1)
+}
+
+// Panic:
+// runtime error: integer overflow
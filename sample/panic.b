@@ -0,0 +1,26 @@
+package main
+
+type T {
+	U
+}
+
+func (t T) f(s) {
+	panic(s)
+}
+
+func g(s) {
+	defer println("g unwinding")
+	U.f(s)
+}
+
+func main() {
+	g("boom")
+	println("not reached")
+}
+
+// Panic:
+// boom
+//
+// main.T.f
+// main.g
+// main.main
@@ -0,0 +1,10 @@
+package main
+
+func main() {
+	panic(recover())
+}
+
+// Panic:
+// panic called with nil argument
+//
+// main.main
@@ -0,0 +1,54 @@
+package main
+
+type Failure {
+	Err(msg)
+}
+
+func try(v) {
+	defer func() {
+		println("recovered", recover())
+	}()
+	panic(v)
+}
+
+func rethrow() {
+	defer func() {
+		r := recover()
+		if r != nil {
+			println("rethrowing", r)
+			panic(r)
+		}
+	}()
+	panic([1, 2])
+}
+
+func same() {
+	defer func() {
+		r := recover()
+		println(r == r, r == nil)
+	}()
+	panic(1.5)
+}
+
+func main() {
+	try(1)
+	try("two")
+	try([3])
+	try(Err("four"))
+	try(nil)
+	same()
+	defer func() {
+		println("outer", recover())
+	}()
+	rethrow()
+}
+
+// Output:
+// recovered 1
+// recovered two
+// recovered [ 3 ]
+// recovered [ 'four' ]
+// recovered panic called with nil argument
+// 1 0
+// rethrowing [ 1, 2 ]
+// outer [ 1, 2 ]
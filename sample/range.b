@@ -0,0 +1,72 @@
+package main
+
+func count(xs) {
+	n := 0
+	for range xs {
+		n++
+	}
+	return n
+}
+
+func main() {
+	for i, r := range "aé€😀" {
+		println(i, r)
+	}
+	for i := range "\xffb" {
+		println(i)
+	}
+	xs := [10, 20, 30, 40]
+	fs := []
+	for i, x := range xs {
+		if i == 1 {
+			continue
+		}
+		if x > 30 {
+			break
+		}
+		fs = append(fs, func() {
+			return i + x
+		})
+	}
+	for _, f := range fs {
+		println(f())
+	}
+	i, x := 0, 0
+	for i, x = range xs {
+	}
+	println(i, x)
+	i, ys := 0, [0, 0, 0]
+	for i, ys[i] = range xs {
+		if i == 2 {
+			break
+		}
+	}
+	println(ys)
+	println(count("héllo"), count(xs), count([]))
+outer:
+	for _, x := range xs {
+		for _, y := range xs {
+			if x+y == 50 {
+				println(x, y)
+				continue outer
+			}
+		}
+	}
+}
+
+// Output:
+// 0 97
+// 1 233
+// 3 8364
+// 6 128512
+// 0
+// 1
+// 10
+// 32
+// 3 40
+// [ 20, 30, 0 ]
+// 5 4 0
+// 10 40
+// 20 30
+// 30 20
+// 40 10
@@ -0,0 +1,31 @@
+package main
+
+func f() {
+	for i := range 5 {
+		println(i)
+	}
+}
+
+func g() {
+	s := ""
+	for _, s = range [1, 2] {
+	}
+	println(s)
+}
+
+func h() {
+	for i, r := range "abc" {
+		println(i + "!", r)
+	}
+}
+
+func main() {
+	f()
+	g()
+	h()
+}
+
+// Error:
+// 4:17: cannot range over number
+// 11:9: cannot use number as string in range
+// 18:13: invalid operation: mismatched types int and string
@@ -0,0 +1,31 @@
+package main
+
+func name(p) {
+	return p.name
+}
+
+func older(p) {
+	return {name: p.name, age: p.age + 1}
+}
+
+func main() {
+	p := {name: "gopher", age: 3}
+	println(p.name, p.age)
+	q := older(p)
+	println(name(q), q.age)
+	println(name({age: 1, name: "bubble"}))
+	println({a: 1, b: 2}.b)
+	r := {x: 1}
+	r = {x: 2}
+	println(r.x)
+	l := [{n: 1}, {n: 2}]
+	println(l[1].n)
+}
+
+// Output:
+// gopher 3
+// gopher 4
+// bubble
+// 2
+// 2
+// 2
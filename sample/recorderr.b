@@ -0,0 +1,9 @@
+package main
+
+func main() {
+	p := {a: 1, a: 2}
+	println(p.a)
+}
+
+// Error:
+// 4:14: duplicate field name a in record literal
@@ -0,0 +1,9 @@
+package main
+
+func main() {
+	p := {a: 1}
+	println(p.b)
+}
+
+// Error:
+// 5:12: record has no field b
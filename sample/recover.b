@@ -0,0 +1,58 @@
+package main
+
+type R {
+	Rec
+}
+
+func (r R) handle() {
+	println("method", recover())
+}
+
+func helper() {
+	println("helper", recover())
+}
+
+func handle() {
+	println("handle", recover())
+}
+
+func a() {
+	defer handle()
+	panic("a")
+}
+
+func b() {
+	defer func() {
+		helper()
+	}()
+	panic("b")
+}
+
+func c() {
+	defer func() {
+		println("c", recover())
+	}()
+	b()
+}
+
+func d() {
+	defer Rec.handle()
+	defer recover()
+	panic("d")
+}
+
+func main() {
+	a()
+	c()
+	d()
+	helper()
+	println("done")
+}
+
+// Output:
+// handle a
+// helper nil
+// c b
+// method d
+// helper nil
+// done
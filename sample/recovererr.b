@@ -0,0 +1,23 @@
+package main
+
+func f() {
+	r := recover()
+	println(r.name)
+}
+
+func g() {
+	if recover() == "" {
+		println("no panic")
+	}
+}
+
+func main() {
+	f()
+	g()
+	println(nil + nil)
+}
+
+// Error:
+// 5:12: any has no field name
+// 9:15: invalid operation: mismatched types any and string
+// 17:14: invalid operation: operator + not defined on any
@@ -0,0 +1,15 @@
+package main
+
+func helper() {
+	recover()
+}
+
+func main() {
+	defer func() {
+		helper()
+	}()
+	panic("not recovered")
+}
+
+// Panic:
+// not recovered
@@ -0,0 +1,33 @@
+package main
+
+func kind(c) {
+	switch c {
+	case 'a':
+		return "letter a"
+	case '\n':
+		return "newline"
+	case '世':
+		return "world"
+	}
+	return "other"
+}
+
+func main() {
+	println('a', 'Z', '\n', '\'', '\\', '\x7f', '\u00e9', '世', '\U0001F600')
+	println(string('é'), 'a' + 1, string('a' + 1))
+	println(kind('a'), kind(10), kind(0x4e16), kind('b'))
+	println(`raw \n "quoted" \t`)
+	s := `two
+lines`
+	println(s, len(s))
+	println("</script>", len("</script>"), "\x00\a\b\f\r\v" == "\000\007\010\014\015\013")
+}
+
+// Output:
+// 97 90 10 39 92 127 233 19990 128512
+// é 98 b
+// letter a newline world other
+// raw \n "quoted" \t
+// two
+// lines 9
+// </script> 9 1
@@ -0,0 +1,31 @@
+package main
+
+import "test"
+import t "test"
+
+func f(x, x) {
+	println(y)
+}
+
+func f() {
+}
+
+func main() {
+	test.F()
+	test.G()
+	test.f()
+	println(test)
+	func(z) {
+		println(z)
+	}(z)
+}
+
+// Error:
+// 4:8: "test" imported as t and not used
+// 6:11: x redeclared in this block
+// 7:10: undefined: y
+// 10:6: f redeclared in this block
+// 15:7: undefined: test.G
+// 16:7: name f not exported by package test
+// 17:10: use of package test without selector
+// 20:4: undefined: z
@@ -0,0 +1,10 @@
+package main
+
+func main() {
+	s := "abc"
+	_ = s[2:10]
+	println("not reached")
+}
+
+// Panic:
+// runtime error: slice bounds out of range
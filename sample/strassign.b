@@ -0,0 +1,30 @@
+package main
+
+func set(a, i, x) {
+	a[i] = x
+}
+
+func str() {
+	s := "abc"
+	s[0] = 'x'
+}
+
+func generic() {
+	set("abc", 0, 'x')
+}
+
+func num() {
+	n := 5
+	n[0]++
+}
+
+func main() {
+	str()
+	generic()
+	num()
+}
+
+// Error:
+// 9:3: cannot assign to index of string
+// 13:6: cannot use string as list or map in argument
+// 18:3: cannot assign to index of number
@@ -0,0 +1,9 @@
+package main
+
+func main() {
+	s := "héllo"
+	println(s[2:10])
+}
+
+// Panic:
+// runtime error: slice bounds out of range [2:10] with length 6
@@ -0,0 +1,45 @@
+package main
+
+func reverse(s) {
+	r := runes(s)
+	t := ""
+	for i := len(r) - 1; i >= 0; i-- {
+		t = t + string(r[i])
+	}
+	return t
+}
+
+func main() {
+	s := "héllo, 世界"
+	println(s, len(s), len(runes(s)))
+	println(s[0], s[1], s[2], s[1:3], s[:5], s[8:])
+	println(reverse(s))
+	println("abc" < "abd", "ab" < "abc", "é" > "z", "x" == "x", "a" != "b")
+	println(itoa(42) + "!", itoa(-7), len(itoa(1234567)))
+	n, ok := atoi("-123")
+	m, bad := atoi("12a")
+	println(n+1, ok, m, bad)
+	for i := 0; i < len(s); {
+		r, size := decoderune(s, i)
+		if size > 1 {
+			println(i, r, size, string(r))
+		}
+		i = i + size
+	}
+	r, size := decoderune("\xff", 0)
+	println(r, size, string(-1) == "�", string(0x4e16))
+	println(["a", "é"], {name: "ünï"})
+}
+
+// Output:
+// héllo, 世界 14 9
+// 104 195 169 é héll 世界
+// 界世 ,olléh
+// 1 1 1 1 1
+// 42! -7 7
+// -122 1 0 0
+// 1 233 2 é
+// 8 19990 3 世
+// 11 30028 3 界
+// 65533 1 1 世
+// [ 'a', 'é' ] [ 'name', 'ünï' ]
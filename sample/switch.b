@@ -0,0 +1,73 @@
+package main
+
+func day(n) {
+	switch n {
+	case 0, 6:
+		return "weekend"
+	case 1, 2, 3, 4:
+		return "weekday"
+	case 5:
+		return "friday"
+	}
+	return "unknown"
+}
+
+func sparse(n) {
+	s := "other"
+	switch n {
+	case -100:
+		s = "minus hundred"
+	case 1:
+		s = "one"
+	case 1000:
+		s = "thousand"
+	case 70000:
+		s = "big"
+	default:
+		s = "default"
+	}
+	return s
+}
+
+func main() {
+	for i := -1; i < 8; i++ {
+		println(i, day(i))
+	}
+	l := [-100, 1, 2, 1000, 70000, 0]
+	for i := 0; i < len(l); i++ {
+		println(l[i], sparse(l[i]))
+	}
+	for i := 0; i < 4; i++ {
+		switch i {
+		case 1:
+			continue
+		case 2:
+			break
+		default:
+			println("default", i)
+		}
+		println("end", i)
+	}
+}
+
+// Output:
+// -1 unknown
+// 0 weekend
+// 1 weekday
+// 2 weekday
+// 3 weekday
+// 4 weekday
+// 5 friday
+// 6 weekend
+// 7 unknown
+// -100 minus hundred
+// 1 one
+// 2 default
+// 1000 thousand
+// 70000 big
+// 0 default
+// default 0
+// end 0
+// end 2
+// default 3
+// end 3
@@ -0,0 +1,16 @@
+package main
+
+func f(n) {
+	switch n {
+	case 9007199254740993:
+		return 1
+	}
+	return 0
+}
+
+func main() {
+	println(f(1))
+}
+
+// Error:
+// 5:7: constant 9007199254740993 overflows int
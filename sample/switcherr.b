@@ -0,0 +1,28 @@
+package main
+
+type T {
+	A
+}
+
+func main() {
+	x := 1
+	switch x {
+	case 1, 2:
+	case 2:
+	case A:
+	case x:
+	default:
+	default:
+	}
+	switch A {
+	case A:
+	case 3:
+	}
+}
+
+// Error:
+// 11:7: duplicate case 2 in switch
+// 12:7: constructor case A in switch on integers
+// 13:7: x is not a constructor
+// 15:2: multiple defaults in switch
+// 19:7: integer case 3 in switch on T
@@ -0,0 +1,41 @@
+package main
+
+// Each case body is converted once, however
+// many patterns its case has, so nesting
+// such switches does not blow up.
+func count(x) {
+	n := 0
+	switch x {
+	case 1, 2, 3, 4, 5, 6, 7, 8:
+		switch x {
+		case 1, 2, 3, 4, 5, 6, 7, 8:
+			switch x {
+			case 1, 2, 3, 4, 5, 6, 7, 8:
+				switch x {
+				case 1, 2, 3, 4, 5, 6, 7, 8:
+					switch x {
+					case 1, 2, 3, 4, 5, 6, 7, 8:
+						switch x {
+						case 1, 2, 3, 4, 5, 6, 7, 8:
+							switch x {
+							case 1, 2, 3, 4, 5, 6, 7, 8:
+								switch x {
+								case 1, 2, 3, 4, 5, 6, 7, 8:
+									n++
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return n
+}
+
+func main() {
+	println(count(3), count(9))
+}
+
+// Output:
+// 1 0
@@ -0,0 +1,26 @@
+package main
+
+func f(x y z) {
+	println(x)
+}
+
+func main() {
+	println(1 +)
+	println("ok"
+	if 1 {
+		println(2) 3
+	}
+	f(1, 2)
+}
+
+x := 1
+
+func g() {
+}
+
+// Error:
+// 3:12: expected ',', found z
+// 8:13: expected operand, found ')'
+// 9:14: expected ',', found newline
+// 11:14: expected ';', found 3
+// 16:1: expected declaration, found x
@@ -0,0 +1,10 @@
+package main
+
+func main() {
+	f := 1e300
+	_ = int(f)
+	println("not reached")
+}
+
+// Panic:
+// runtime error: integer overflow
@@ -0,0 +1,31 @@
+package main
+
+func f() {
+	return 1 + "a"
+}
+
+func g(s) {
+	return s + 1 + itoa(s)
+}
+
+func h(p) {
+	p = {a: 1}
+	return p.b
+}
+
+func k() {
+	if "yes" {
+		return 1
+	}
+	return 0
+}
+
+func main() {
+	f()
+}
+
+// Error:
+// 4:11: invalid operation: mismatched types number and string
+// 8:15: invalid operation: mismatched types int and string
+// 13:11: record has no field b
+// 17:5: non-boolean condition in if statement
@@ -0,0 +1,40 @@
+package main
+
+import "test"
+
+func first(a) {
+	return a[0]
+}
+
+func mapf(f, a) {
+	b := []
+	for i := 0; i < len(a); i++ {
+		b = append(b, f(a[i]))
+	}
+	return b
+}
+
+func name(p) {
+	return p.name
+}
+
+func get(o, def) {
+	switch o {
+	case test.Some(x):
+		return x
+	}
+	return def
+}
+
+func main() {
+	println(first([1, 2]), first(["a", "b"]), first("xyz"))
+	println(mapf(&x + 1, [1, 2, 3]), mapf(&x + "!", ["a", "bc"]))
+	println(name({name: "ann", age: 30}), name({name: "bob"}))
+	println(get(test.Some(1), 2), get(test.Some("one"), "two"))
+}
+
+// Output:
+// 1 a 120
+// [ 2, 3, 4 ] [ 'a!', 'bc!' ]
+// ann bob
+// 1 one
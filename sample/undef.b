@@ -8,5 +8,5 @@ func main() {
 	f(1)
 }
 
-// Output:
-// undefined
+// Error:
+// 8:2: wrong number of arguments to f: have 1, want 2
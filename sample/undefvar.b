@@ -0,0 +1,9 @@
+package main
+
+func main() {
+	println(1)
+	println(x + 1)
+}
+
+// Error:
+// 5:10: undefined: x
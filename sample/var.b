@@ -0,0 +1,51 @@
+package main
+
+import "test"
+
+var total = sum(counts)
+
+var counts = [first, second, test.Count]
+
+var first, second = pair()
+
+var (
+	calls = 0
+	_     = note("side effect")
+)
+
+func pair() {
+	calls = calls + 1
+	return 1, 2
+}
+
+func sum(l) {
+	n := 0
+	for i := 0; i < len(l); i++ {
+		n = n + l[i]
+	}
+	return n
+}
+
+func note(s) {
+	println(s)
+	return 0
+}
+
+func init() {
+	println("init", total)
+	total = total + 1
+}
+
+func init() {
+	println("init again", total)
+}
+
+func main() {
+	println(first, second, counts[2], total, calls)
+}
+
+// Output:
+// side effect
+// init 23
+// init again 24
+// 1 2 20 24 1
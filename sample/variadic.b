@@ -0,0 +1,44 @@
+package main
+
+func sum(xs...) {
+	n := 0
+	for i := 0; i < len(xs); i++ {
+		n = n + xs[i]
+	}
+	return n
+}
+
+func tag(name, xs...) {
+	println(name, len(xs), xs)
+}
+
+func apply(f, x, y) {
+	return f(x, y)
+}
+
+func main() {
+	println(sum(), sum(1), sum(1, 2, 3))
+	tag("a")
+	tag("b", 1, 2)
+	s := sum
+	println(s(4, 5, 6))
+	println(apply(sum, 7, 8))
+	println(apply(func(a, b) {
+		return a * b
+	}, 3, 4))
+	println(apply(&x-y, 9, 2))
+	f := func(first, rest...) {
+		return len(rest)
+	}
+	println(f(1), f(1, 2, 3))
+}
+
+// Output:
+// 0 1 6
+// a 0 []
+// b 2 [ 1, 2 ]
+// 15
+// 15
+// 12
+// 7
+// 0 2
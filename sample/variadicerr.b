@@ -0,0 +1,35 @@
+package main
+
+func add(x, y) {
+	return x + y
+}
+
+func first(xs) {
+	return xs[0]
+}
+
+func sum(xs...) {
+	n := 0
+	for i := 0; i < len(xs); i++ {
+		n = n + xs[i]
+	}
+	return n
+}
+
+func main() {
+	f := add
+	f = sum
+	g := sum
+	g = add
+	println(f(1, 2), g(1, 2))
+}
+
+func h() {
+	f := sum
+	f = first
+	println(f(1))
+}
+
+// Error:
+// 23:6: cannot use func(number or string, number or string) number or string as func(...number) number in assignment
+// 29:6: cannot use func(string, list, or map) a as func(...number) number in assignment
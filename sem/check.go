@@ -0,0 +1,978 @@
+// Package sem checks Bubble programs for errors
+// the parser cannot detect, such as references
+// to undefined names.
+//
+// The checks happen before conversion to the
+// functional language in package fun, so that
+// every problem in a package can be reported at once
+// with its source position.
+package sem
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"strconv"
+
+	"github.com/kr/bubble/ast"
+	"github.com/kr/bubble/fun"
+)
+
+type objKind int
+
+const (
+	builtinObj objKind = iota
+	pkgObj
+	funcObj
+	varObj
+	labelObj
+	typeObj
+	conObj
+	constObj
+)
+
+// An object is something a name can refer to.
+type object struct {
+	kind   objKind
+	tab    fun.Tab // for pkgObj
+	typ    string  // for conObj, the name of its type
+	arity  int     // for conObj and funcObj
+	vararg bool    // for funcObj
+	used   bool
+	imp    *object         // for a name from a dot import, the import
+	iface  bool            // for typeObj, whether it is an interface
+	meths  map[string]bool // for a local data type, its methods
+}
+
+// importedObj returns the object for name,
+// exported by the package tab
+// and imported by the dot import imp.
+func importedObj(tab fun.Tab, name string, imp *object) *object {
+	obj := &object{kind: constObj, imp: imp}
+	if n, vararg, ok := tab.Func(name); ok {
+		obj.kind, obj.arity, obj.vararg = funcObj, n, vararg
+	} else if typ, n, ok := tab.Con(name); ok {
+		obj.kind, obj.typ, obj.arity = conObj, typ, n
+	} else if tab.Var(name) {
+		obj.kind = varObj
+	} else if tab.Type(name) {
+		obj.kind = typeObj
+	}
+	return obj
+}
+
+// universe is the object for every builtin name.
+var universe = &object{kind: builtinObj}
+
+type scope struct {
+	outer *scope
+	objs  map[string]*object
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{outer, make(map[string]*object)}
+}
+
+// lookup returns the object bound to name
+// in s or any enclosing scope,
+// or nil if there is none.
+func (s *scope) lookup(name string) *object {
+	for ; s != nil; s = s.outer {
+		if obj := s.objs[name]; obj != nil {
+			if obj.imp != nil {
+				obj.imp.used = true
+			}
+			return obj
+		}
+	}
+	if fun.Builtin(name) {
+		return universe
+	}
+	return nil
+}
+
+type checker struct {
+	fset   *token.FileSet
+	errors scanner.ErrorList
+
+	// for the function being checked
+	targets []target           // enclosing loops and switches
+	labels  map[string]*object // declared labels
+}
+
+// A target is a statement that break,
+// and for a loop continue, can refer to.
+type target struct {
+	label *ast.Ident // nil if unlabeled
+	loop  bool
+}
+
+// Check checks p for semantic errors.
+// Function pkgtab must return the symbol table
+// of any package imported by p, as for fun.Convert.
+// If there are errors, the returned error is a
+// scanner.ErrorList describing every one.
+func Check(fset *token.FileSet, p *ast.Package, pkgtab func(importPath string) fun.Tab) error {
+	c := &checker{fset: fset}
+	pkgScope := newScope(nil)
+	hasMain := false
+	for _, file := range p.Files {
+		for _, t := range file.Types {
+			obj := &object{kind: typeObj, iface: t.Interface.IsValid(), meths: make(map[string]bool)}
+			c.declare(pkgScope, t.Name.Name, t.Name.Pos(), obj)
+			seen := make(map[string]bool)
+			for _, m := range t.Methods {
+				if seen[m.Name.Name] {
+					c.errorf(m.Name.Pos(), "duplicate method %s", m.Name.Name)
+				}
+				seen[m.Name.Name] = true
+			}
+			for _, con := range t.Cons {
+				obj := &object{kind: conObj, typ: t.Name.Name, arity: len(con.Params)}
+				c.declare(pkgScope, con.Name.Name, con.Name.Pos(), obj)
+				fields := newScope(nil)
+				for _, p := range con.Params {
+					c.declare(fields, p.Name, p.Pos(), &object{kind: varObj})
+				}
+			}
+		}
+		for _, f := range file.Funcs {
+			if f.Name.Name == "init" {
+				continue // init is not bound
+			}
+			if f.Name.Name == "main" {
+				hasMain = true
+			}
+			obj := &object{kind: funcObj, arity: len(f.Params), vararg: f.Ellipsis.IsValid()}
+			c.declare(pkgScope, f.Name.Name, f.Name.Pos(), obj)
+		}
+		for _, d := range file.Values {
+			kind := varObj
+			if d.Tok == token.CONST {
+				kind = constObj
+			}
+			for _, spec := range d.Specs {
+				for _, id := range spec.Names {
+					c.declare(pkgScope, id.Name, id.Pos(), &object{kind: kind})
+				}
+			}
+		}
+	}
+	for _, file := range p.Files {
+		for _, f := range file.Methods {
+			t := pkgScope.objs[f.RecvType.Name]
+			if t == nil || t.kind != typeObj || t.iface {
+				c.errorf(f.RecvType.Pos(), "invalid receiver type %s", f.RecvType.Name)
+				continue
+			}
+			if t.meths[f.Name.Name] {
+				c.errorf(f.Name.Pos(), "method %s.%s already declared", f.RecvType.Name, f.Name.Name)
+			}
+			t.meths[f.Name.Name] = true
+		}
+	}
+	c.checkCycles(p)
+	if p.Name == "main" && !hasMain && len(p.Files) > 0 {
+		c.errorf(p.Files[0].Name.Pos(), "function main is undeclared in the main package")
+	}
+
+	for _, file := range p.Files {
+		fileScope := newScope(pkgScope)
+		var imports []*object
+		seen := make(map[string]bool) // name and path of each import
+		for _, spec := range file.Imports {
+			path := spec.Path.String()
+			tab := pkgtab(path)
+			name := tab.Name()
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			obj := &object{kind: pkgObj, tab: tab}
+			imports = append(imports, obj)
+			if seen[name+" "+path] {
+				c.errorf(spec.Pos(), "%s imported more than once", strconv.Quote(path))
+				obj.used = true
+				continue
+			}
+			seen[name+" "+path] = true
+			switch name {
+			case "_":
+				obj.used = true // imported only for its initialization
+			case ".":
+				for _, n := range tab.Names() {
+					if pkgScope.objs[n] != nil {
+						c.errorf(spec.Pos(), "%s already declared through dot-import of package %s", n, tab.Name())
+					}
+					c.declare(fileScope, n, spec.Pos(), importedObj(tab, n, obj))
+				}
+			default:
+				if pkgScope.objs[name] != nil {
+					c.errorf(spec.Pos(), "%s already declared through import of package %s", name, tab.Name())
+				}
+				c.declare(fileScope, name, spec.Pos(), obj)
+			}
+		}
+		for _, d := range file.Values {
+			c.checkGenDecl(d, fileScope)
+		}
+		for _, t := range file.Types {
+			for _, m := range t.Methods {
+				c.checkType(m.Value, fileScope)
+			}
+		}
+		for _, f := range file.Funcs {
+			c.checkSig(f.Types, f.Result, fileScope)
+			c.checkFunc(f.Params, f.Body, fileScope)
+		}
+		for _, f := range file.Methods {
+			c.checkSig(f.Types, f.Result, fileScope)
+			c.checkFunc(append([]*ast.Ident{f.Recv}, f.Params...), f.Body, fileScope)
+		}
+		for i, obj := range imports {
+			if obj.used {
+				continue
+			}
+			spec := file.Imports[i]
+			path := strconv.Quote(spec.Path.String())
+			if spec.Name != nil && spec.Name.Name != "." {
+				c.errorf(spec.Pos(), "%s imported as %s and not used", path, spec.Name.Name)
+			} else {
+				c.errorf(spec.Pos(), "%s imported and not used", path)
+			}
+		}
+	}
+	c.errors.Sort()
+	return c.errors.Err()
+}
+
+func (c *checker) errorf(pos token.Pos, format string, v ...interface{}) {
+	c.errors.Add(c.fset.Position(pos), fmt.Sprintf(format, v...))
+}
+
+// declare binds name to obj in s.
+// It is an error for s itself to already
+// have a binding for name.
+func (c *checker) declare(s *scope, name string, pos token.Pos, obj *object) {
+	if name == "_" {
+		return
+	}
+	if s.objs[name] != nil {
+		c.errorf(pos, "%s redeclared in this block", name)
+		return
+	}
+	s.objs[name] = obj
+}
+
+// checkFunc checks a function with the given params and body.
+// The params are in the same scope as the top level of body.
+func (c *checker) checkFunc(params []*ast.Ident, body ast.Node, s *scope) {
+	targets, labels := c.targets, c.labels
+	c.targets, c.labels = nil, make(map[string]*object)
+	s = newScope(s)
+	for _, p := range params {
+		c.declare(s, p.Name, p.Pos(), &object{kind: varObj})
+	}
+	if b, ok := body.(*ast.BlockStmt); ok {
+		c.checkList(b.List, s)
+	} else {
+		c.check(body, s)
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit, *ast.ShortFuncLit:
+			return false
+		case *ast.LabeledStmt:
+			if !c.labels[n.Label.Name].used {
+				c.errorf(n.Label.Pos(), "label %s defined and not used", n.Label.Name)
+			}
+		}
+		return true
+	})
+	c.targets, c.labels = targets, labels
+}
+
+// checkCycles checks that no interface declared in p
+// refers to itself, directly or through others.
+func (c *checker) checkCycles(p *ast.Package) {
+	ifaces := make(map[string]*ast.TypeDecl)
+	for _, file := range p.Files {
+		for _, t := range file.Types {
+			if t.Interface.IsValid() {
+				ifaces[t.Name.Name] = t
+			}
+		}
+	}
+	for name, t := range ifaces {
+		seen := make(map[string]bool)
+		var refers func(t *ast.TypeDecl) bool
+		refers = func(t *ast.TypeDecl) bool {
+			found := false
+			for _, m := range t.Methods {
+				ast.Inspect(m.Value, func(n ast.Node) bool {
+					id, ok := n.(*ast.Ident)
+					if !ok || found || ifaces[id.Name] == nil {
+						return !found
+					}
+					if id.Name == name {
+						found = true
+					} else if !seen[id.Name] {
+						seen[id.Name] = true
+						found = refers(ifaces[id.Name])
+					}
+					return !found
+				})
+			}
+			return found
+		}
+		if refers(t) {
+			c.errorf(t.Name.Pos(), "invalid recursive type %s", name)
+		}
+	}
+}
+
+// checkSig checks the types annotating
+// the params and result of a function, if any.
+func (c *checker) checkSig(types []ast.Expr, result ast.Expr, s *scope) {
+	for _, x := range types {
+		if x != nil {
+			c.checkType(x, s)
+		}
+	}
+	if result != nil {
+		c.checkType(result, s)
+	}
+}
+
+// checkType checks that each name in type x
+// refers to a type.
+func (c *checker) checkType(x ast.Expr, s *scope) {
+	switch x := x.(type) {
+	case *ast.Ident:
+		if x.Name == "_" {
+			return
+		}
+		obj := s.lookup(x.Name)
+		switch {
+		case obj == nil:
+			c.errorf(x.Pos(), "undefined: %s", x.Name)
+		case obj == universe && fun.BuiltinType(x.Name), obj.kind == typeObj:
+		default:
+			c.errorf(x.Pos(), "%s is not a type", x.Name)
+		}
+	case *ast.SelectorExpr:
+		id := x.X.(*ast.Ident)
+		obj := s.lookup(id.Name)
+		switch {
+		case obj == nil:
+			c.errorf(id.Pos(), "undefined: %s", id.Name)
+		case obj.kind != pkgObj:
+			c.errorf(x.Pos(), "%s.%s is not a type", id.Name, x.Sel.Name)
+		default:
+			obj.used = true
+			c.checkSelection(id, obj.tab, x.Sel)
+			if obj.tab.Has(x.Sel.Name) && !obj.tab.Type(x.Sel.Name) {
+				c.errorf(x.Pos(), "%s.%s is not a type", id.Name, x.Sel.Name)
+			}
+		}
+	case *ast.ListType:
+		c.checkType(x.Elem, s)
+	case *ast.MapType:
+		c.checkType(x.Key, s)
+		c.checkType(x.Value, s)
+	case *ast.FuncType:
+		for _, p := range x.Params {
+			c.checkType(p, s)
+		}
+		if x.Result != nil {
+			c.checkType(x.Result, s)
+		}
+	case *ast.RecordType:
+		seen := make(map[string]bool)
+		for _, f := range x.Fields {
+			if seen[f.Name.Name] {
+				c.errorf(f.Name.Pos(), "duplicate field name %s in record type", f.Name.Name)
+			}
+			seen[f.Name.Name] = true
+			c.checkType(f.Value, s)
+		}
+	case *ast.TupleType:
+		for _, t := range x.Types {
+			c.checkType(t, s)
+		}
+	}
+}
+
+// checkGenDecl checks the initializers
+// of var or const declaration d.
+func (c *checker) checkGenDecl(d *ast.GenDecl, s *scope) {
+	var last []ast.Expr // values repeated for a const spec without any
+	for _, spec := range d.Specs {
+		nl, nr := len(spec.Names), len(spec.Values)
+		if d.Tok == token.VAR {
+			if nl != nr && !(nr == 1 && multivalued(spec.Values[0], nl)) {
+				c.mismatch(spec.Pos(), nl, nr)
+			}
+			for _, x := range spec.Values {
+				c.check(x, s)
+			}
+			continue
+		}
+		if nr > 0 {
+			last = spec.Values
+		}
+		switch {
+		case nl > len(last):
+			c.errorf(spec.Names[len(last)].Pos(), "missing init expr for const declaration")
+		case nl < nr:
+			c.errorf(spec.Values[nl].Pos(), "extra init expr")
+		}
+		cs := newScope(s)
+		cs.objs["iota"] = &object{kind: constObj}
+		for j, x := range spec.Values {
+			c.check(x, cs)
+			if j < nl && !c.isConst(x, cs) {
+				c.errorf(x.Pos(), "const initializer for %s is not a constant", spec.Names[j].Name)
+			}
+		}
+	}
+}
+
+// isConst returns whether x is a constant expression.
+// Undefined names, which are reported elsewhere,
+// count as constant.
+func (c *checker) isConst(x ast.Expr, s *scope) bool {
+	switch x := x.(type) {
+	case *ast.BasicLit:
+		return true
+	case *ast.Ident:
+		obj := s.lookup(x.Name)
+		return obj == nil || obj.kind == constObj || obj == universe && fun.BuiltinConst(x.Name)
+	case *ast.SelectorExpr:
+		if id, ok := x.X.(*ast.Ident); ok {
+			if obj := s.lookup(id.Name); obj != nil && obj.kind == pkgObj {
+				return !obj.tab.Has(x.Sel.Name) || obj.tab.Const(x.Sel.Name)
+			}
+		}
+	case *ast.CallExpr:
+		// conversions of constants are constant
+		id, ok := x.Fun.(*ast.Ident)
+		conv := ok && (id.Name == "int" || id.Name == "float") && s.lookup(id.Name) == universe
+		return conv && len(x.Args) == 1 && c.isConst(x.Args[0], s)
+	case *ast.UnaryExpr:
+		return c.isConst(x.X, s)
+	case *ast.BinaryExpr:
+		return c.isConst(x.X, s) && c.isConst(x.Y, s)
+	}
+	return false
+}
+
+// checkFor checks for loop f, whose label is label
+// (nil if it has none).
+func (c *checker) checkFor(f *ast.ForStmt, label *ast.Ident, s *scope) {
+	if f.X != nil {
+		c.check(f.X, s)
+	}
+	s = newScope(s)
+	if f.Key != nil {
+		c.checkRange(f, s)
+	}
+	if f.Init != nil {
+		c.check(f.Init, s)
+	}
+	if f.Cond != nil {
+		c.check(f.Cond, s)
+	}
+	if f.Post != nil {
+		if a, ok := f.Post.(*ast.AssignStmt); ok && a.Tok == token.DEFINE {
+			c.errorf(a.Pos(), "cannot declare in post statement of for loop")
+		}
+		c.check(f.Post, s)
+	}
+	c.targets = append(c.targets, target{label, true})
+	c.check(f.Body, s)
+	c.targets = c.targets[:len(c.targets)-1]
+}
+
+// checkRange checks the names a range loop f assigns,
+// and for := declares them in s, the loop's scope.
+func (c *checker) checkRange(f *ast.ForStmt, s *scope) {
+	lhs := []ast.Expr{f.Key}
+	if f.Value != nil {
+		lhs = append(lhs, f.Value)
+	}
+	if f.Tok != token.DEFINE {
+		for _, x := range lhs {
+			c.checkAssign(x, s)
+		}
+		return
+	}
+	for _, x := range lhs {
+		id, ok := x.(*ast.Ident)
+		switch {
+		case !ok:
+			c.errorf(x.Pos(), "non-name on left side of :=")
+		case id.Name == "_":
+		case s.objs[id.Name] != nil:
+			c.errorf(id.Pos(), "%s repeated on left side of :=", id.Name)
+		default:
+			c.declare(s, id.Name, id.Pos(), &object{kind: varObj})
+		}
+	}
+}
+
+// checkSwitch checks switch statement sw,
+// whose label is label (nil if it has none).
+func (c *checker) checkSwitch(sw *ast.SwitchStmt, label *ast.Ident, s *scope) {
+	c.check(sw.Tag, s)
+	c.targets = append(c.targets, target{label, false})
+	var typ string // type of the constructors in the cases so far
+	ints := false  // whether the cases are integers
+	seen := make(map[string]bool)
+	hasDefault := false
+	for _, cc := range sw.Cases {
+		cs := newScope(s)
+		if cc.List == nil {
+			if hasDefault {
+				c.errorf(cc.Pos(), "multiple defaults in switch")
+			}
+			hasDefault = true
+		}
+		for _, x := range cc.List {
+			if v, ok := fun.BigPattern(x); ok {
+				name := v.String()
+				switch {
+				case typ != "":
+					c.errorf(x.Pos(), "integer case %s in switch on %s", name, typ)
+				case seen[name]:
+					c.errorf(x.Pos(), "duplicate case %s in switch", name)
+				}
+				ints = true
+				seen[name] = true
+				continue
+			}
+			name, obj := c.checkPattern(x, len(cc.List) > 1, cs, s)
+			if obj == nil {
+				continue
+			}
+			switch {
+			case ints:
+				c.errorf(x.Pos(), "constructor case %s in switch on integers", name)
+				continue
+			case typ == "":
+				typ = obj.typ
+			case obj.typ != typ:
+				c.errorf(x.Pos(), "%s is a constructor of %s, not %s", name, obj.typ, typ)
+			}
+			if seen[name] {
+				c.errorf(x.Pos(), "duplicate case %s in switch", name)
+			}
+			seen[name] = true
+		}
+		c.checkList(cc.Body, cs)
+	}
+	c.targets = c.targets[:len(c.targets)-1]
+}
+
+// checkPattern checks x, a pattern in a case of a switch,
+// and declares its bindings in cs.
+// If multi is set, the case has more than one pattern,
+// and so its patterns must not bind any names.
+// It returns the constructor's name and object,
+// or a nil object if x is not a valid constructor.
+func (c *checker) checkPattern(x ast.Expr, multi bool, cs, s *scope) (string, *object) {
+	var params []ast.Expr
+	fn := x
+	call, isCall := x.(*ast.CallExpr)
+	if isCall {
+		fn, params = call.Fun, call.Args
+	}
+	name, obj := c.lookupCon(fn, s)
+	if obj == nil {
+		return name, nil
+	}
+	switch {
+	case isCall && obj.arity == 0:
+		c.errorf(x.Pos(), "constructor %s has no fields", name)
+	case len(params) != obj.arity:
+		c.errorf(x.Pos(), "wrong number of fields in pattern %s: have %d, want %d", name, len(params), obj.arity)
+	}
+	for _, p := range params {
+		id, ok := p.(*ast.Ident)
+		switch {
+		case !ok:
+			c.errorf(p.Pos(), "invalid pattern: field must be a name")
+		case multi && id.Name != "_":
+			c.errorf(p.Pos(), "cannot bind %s in case with multiple patterns", id.Name)
+		default:
+			c.declare(cs, id.Name, id.Pos(), &object{kind: varObj})
+		}
+	}
+	return name, obj
+}
+
+// lookupCon returns the name and object of
+// constructor x, an identifier or a selection
+// from an imported package.
+// If x is not a constructor, it reports an error
+// and returns a nil object.
+func (c *checker) lookupCon(x ast.Expr, s *scope) (string, *object) {
+	switch x := x.(type) {
+	case *ast.Ident:
+		obj := s.lookup(x.Name)
+		switch {
+		case obj == nil:
+			c.errorf(x.Pos(), "undefined: %s", x.Name)
+		case obj.kind != conObj:
+			c.errorf(x.Pos(), "%s is not a constructor", x.Name)
+		default:
+			return x.Name, obj
+		}
+		return x.Name, nil
+	case *ast.SelectorExpr:
+		if id, ok := x.X.(*ast.Ident); ok {
+			if obj := s.lookup(id.Name); obj != nil && obj.kind == pkgObj {
+				obj.used = true
+				name := id.Name + "." + x.Sel.Name
+				c.checkSelection(id, obj.tab, x.Sel)
+				typ, arity, ok := obj.tab.Con(x.Sel.Name)
+				if !ok {
+					if obj.tab.Has(x.Sel.Name) {
+						c.errorf(x.Pos(), "%s is not a constructor", name)
+					}
+					return name, nil
+				}
+				typ = id.Name + "." + typ
+				return name, &object{kind: conObj, typ: typ, arity: arity}
+			}
+		}
+	}
+	c.errorf(x.Pos(), "invalid pattern")
+	return "", nil
+}
+
+// checkBranch checks break or continue statement b.
+func (c *checker) checkBranch(b *ast.BranchStmt) {
+	loop := b.Tok == token.CONTINUE
+	if b.Label == nil {
+		for _, t := range c.targets {
+			if t.loop || !loop {
+				return
+			}
+		}
+		if loop || len(c.targets) == 0 {
+			c.errorf(b.Pos(), "%s is not in a loop", b.Tok)
+		}
+		return
+	}
+	name := b.Label.Name
+	if l := c.labels[name]; l != nil {
+		l.used = true
+	}
+	for _, t := range c.targets {
+		if t.label != nil && t.label.Name == name && (t.loop || !loop) {
+			return
+		}
+	}
+	c.errorf(b.Label.Pos(), "invalid %s label %s", b.Tok, name)
+}
+
+func (c *checker) checkList(list []ast.Stmt, s *scope) {
+	for _, stmt := range list {
+		c.check(stmt, s)
+	}
+}
+
+func (c *checker) check(node ast.Node, s *scope) {
+	switch node := node.(type) {
+	case *ast.Ident:
+		obj := s.lookup(node.Name)
+		switch {
+		case obj == nil:
+			c.errorf(node.Pos(), "undefined: %s", node.Name)
+		case obj.kind == pkgObj:
+			obj.used = true
+			c.errorf(node.Pos(), "use of package %s without selector", node.Name)
+		case obj.kind == typeObj:
+			c.errorf(node.Pos(), "%s (type) is not an expression", node.Name)
+		case obj == universe && fun.BuiltinFunc(node.Name):
+			c.errorf(node.Pos(), "%s (built-in function %s) must be called", node.Name, node.Name)
+		}
+	case *ast.BasicLit:
+	case *ast.CallExpr:
+		if name, ok := c.typeName(node.Fun, s); ok {
+			if len(node.Args) != 1 {
+				c.errorf(node.Pos(), "wrong number of arguments in conversion to %s: have %d, want 1", name, len(node.Args))
+			}
+		} else {
+			// A builtin may only be called directly.
+			if id, ok := node.Fun.(*ast.Ident); !ok || s.lookup(id.Name) != universe {
+				c.check(node.Fun, s)
+			}
+			c.checkCall(node, s)
+		}
+		for _, x := range node.Args {
+			c.check(x, s)
+		}
+	case *ast.BinaryExpr:
+		c.check(node.X, s)
+		c.check(node.Y, s)
+	case *ast.UnaryExpr:
+		c.check(node.X, s)
+	case *ast.ListLit:
+		for _, x := range node.Elts {
+			c.check(x, s)
+		}
+	case *ast.MapLit:
+		for _, kv := range node.Elts {
+			c.check(kv.Key, s)
+			c.check(kv.Value, s)
+		}
+	case *ast.IndexExpr:
+		c.check(node.X, s)
+		c.check(node.Index, s)
+	case *ast.SliceExpr:
+		c.check(node.X, s)
+		if node.Low != nil {
+			c.check(node.Low, s)
+		}
+		if node.High != nil {
+			c.check(node.High, s)
+		}
+	case *ast.RecordLit:
+		seen := make(map[string]bool)
+		for _, f := range node.Fields {
+			if seen[f.Name.Name] {
+				c.errorf(f.Name.Pos(), "duplicate field name %s in record literal", f.Name.Name)
+			}
+			seen[f.Name.Name] = true
+			c.check(f.Value, s)
+		}
+	case *ast.FuncLit:
+		c.checkSig(node.Types, node.Result, s)
+		c.checkFunc(node.Params, node.Body, s)
+	case *ast.ShortFuncLit:
+		c.checkFunc(node.Params(), node.Body, s)
+	case *ast.BlockStmt:
+		c.checkList(node.List, newScope(s))
+	case *ast.IfStmt:
+		c.check(node.Cond, s)
+		c.check(node.Body, s)
+		if node.Else != nil {
+			c.check(node.Else, s)
+		}
+	case *ast.ExprStmt:
+		c.check(node.X, s)
+	case *ast.DeferStmt:
+		if _, ok := c.typeName(node.Call.Fun, s); ok {
+			c.errorf(node.Call.Pos(), "defer requires function call, not conversion")
+		}
+		c.check(node.Call, s)
+	case *ast.ReturnStmt:
+		for _, x := range node.Results {
+			c.check(x, s)
+		}
+	case *ast.AssignStmt:
+		for _, x := range node.Rhs {
+			c.check(x, s)
+		}
+		c.checkAssignStmt(node, s)
+	case *ast.IncDecStmt:
+		c.checkAssign(node.X, s)
+	case *ast.ForStmt:
+		c.checkFor(node, nil, s)
+	case *ast.SwitchStmt:
+		c.checkSwitch(node, nil, s)
+	case *ast.LabeledStmt:
+		name := node.Label.Name
+		if c.labels[name] != nil {
+			c.errorf(node.Label.Pos(), "label %s already defined", name)
+		} else {
+			c.labels[name] = &object{kind: labelObj}
+		}
+		switch stmt := node.Stmt.(type) {
+		case *ast.ForStmt:
+			c.checkFor(stmt, node.Label, s)
+		case *ast.SwitchStmt:
+			c.checkSwitch(stmt, node.Label, s)
+		default:
+			c.check(node.Stmt, s)
+		}
+	case *ast.BranchStmt:
+		c.checkBranch(node)
+	case *ast.SelectorExpr:
+		if id, ok := node.X.(*ast.Ident); ok {
+			if obj := s.lookup(id.Name); obj != nil && obj.kind == pkgObj {
+				obj.used = true
+				c.checkSelection(id, obj.tab, node.Sel)
+				if obj.tab.Type(node.Sel.Name) {
+					c.errorf(node.Pos(), "%s.%s (type) is not an expression", id.Name, node.Sel.Name)
+				}
+				return
+			}
+		}
+		c.check(node.X, s)
+	default:
+		c.errorf(node.Pos(), "unhandled %T", node)
+	}
+}
+
+// typeName returns the name of the type x refers to,
+// if x is a name or a package selection that
+// refers to a type.
+func (c *checker) typeName(x ast.Expr, s *scope) (string, bool) {
+	switch x := x.(type) {
+	case *ast.Ident:
+		obj := s.lookup(x.Name)
+		return x.Name, obj != nil && obj.kind == typeObj
+	case *ast.SelectorExpr:
+		if id, ok := x.X.(*ast.Ident); ok {
+			if obj := s.lookup(id.Name); obj != nil && obj.kind == pkgObj && obj.tab.Type(x.Sel.Name) {
+				obj.used = true
+				c.checkSelection(id, obj.tab, x.Sel)
+				return id.Name + "." + x.Sel.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// checkCall checks the number of arguments in call
+// if it is a direct call of a constructor
+// or of a function declared at the top level.
+func (c *checker) checkCall(call *ast.CallExpr, s *scope) {
+	var name string
+	var obj *object
+	switch x := call.Fun.(type) {
+	case *ast.Ident:
+		name, obj = x.Name, s.lookup(x.Name)
+	case *ast.SelectorExpr:
+		id, ok := x.X.(*ast.Ident)
+		if !ok {
+			return
+		}
+		if p := s.lookup(id.Name); p != nil && p.kind == pkgObj {
+			name = x.Sel.Name
+			if n, vararg, ok := p.tab.Func(x.Sel.Name); ok {
+				obj = &object{kind: funcObj, arity: n, vararg: vararg}
+			} else if _, n, ok := p.tab.Con(x.Sel.Name); ok {
+				obj = &object{kind: conObj, arity: n}
+			}
+		}
+	}
+	if obj == nil || (obj.kind != funcObj && obj.kind != conObj) {
+		return
+	}
+	have := len(call.Args)
+	switch {
+	case obj.kind == conObj && obj.arity == 0:
+		c.errorf(call.Pos(), "cannot call constructor %s with no fields", name)
+	case obj.vararg && have < obj.arity-1:
+		c.errorf(call.Pos(), "wrong number of arguments to %s: have %d, want at least %d", name, have, obj.arity-1)
+	case !obj.vararg && have != obj.arity:
+		c.errorf(call.Pos(), "wrong number of arguments to %s: have %d, want %d", name, have, obj.arity)
+	}
+}
+
+// multivalued returns whether x can yield n values:
+// if it is a call, or n is 2 and x is an index
+// expression, as in v, ok := m[k].
+func multivalued(x ast.Expr, n int) bool {
+	switch x.(type) {
+	case *ast.CallExpr:
+		return true
+	case *ast.IndexExpr:
+		return n == 2
+	}
+	return false
+}
+
+// mismatch reports an assignment of nr values
+// to nl variables. An assignment of a single
+// value with several results is checked by
+// package types instead.
+func (c *checker) mismatch(pos token.Pos, nl, nr int) {
+	values := "values"
+	if nr == 1 {
+		values = "value"
+	}
+	c.errorf(pos, "assignment mismatch: %d variables but %d %s", nl, nr, values)
+}
+
+// checkAssignStmt checks the left side of assignment a,
+// and for := declares the new variables in s.
+// Names on the left of := that are already
+// declared in s are assigned, as in Go.
+func (c *checker) checkAssignStmt(a *ast.AssignStmt, s *scope) {
+	nl, nr := len(a.Lhs), len(a.Rhs)
+	if nl != nr && !(nr == 1 && multivalued(a.Rhs[0], nl)) {
+		c.mismatch(a.Pos(), nl, nr)
+	}
+	if a.Tok != token.DEFINE {
+		for _, x := range a.Lhs {
+			c.checkAssign(x, s)
+		}
+		return
+	}
+	var decl []*ast.Ident
+	ok := true
+	seen := make(map[string]bool)
+	for _, x := range a.Lhs {
+		id, isIdent := x.(*ast.Ident)
+		switch {
+		case !isIdent:
+			c.errorf(x.Pos(), "non-name on left side of :=")
+			ok = false
+		case id.Name == "_":
+		case seen[id.Name]:
+			c.errorf(id.Pos(), "%s repeated on left side of :=", id.Name)
+			ok = false
+		case s.objs[id.Name] == nil:
+			decl = append(decl, id)
+		default:
+			c.checkAssign(id, s)
+		}
+		if isIdent {
+			seen[id.Name] = true
+		}
+	}
+	if ok && len(decl) == 0 {
+		c.errorf(a.Pos(), "no new variables on left side of :=")
+	}
+	for _, id := range decl {
+		c.declare(s, id.Name, id.Pos(), &object{kind: varObj})
+	}
+}
+
+// checkAssign checks lhs, an operand on the left side
+// of an assignment with = or an increment or decrement.
+func (c *checker) checkAssign(lhs ast.Expr, s *scope) {
+	if x, ok := lhs.(*ast.IndexExpr); ok {
+		c.check(x, s)
+		return
+	}
+	id, ok := lhs.(*ast.Ident)
+	switch {
+	case !ok:
+		c.errorf(lhs.Pos(), "cannot assign to expression")
+	case id.Name == "_":
+	default:
+		obj := s.lookup(id.Name)
+		switch {
+		case obj == nil:
+			c.errorf(id.Pos(), "undefined: %s", id.Name)
+		case obj.kind != varObj:
+			c.errorf(id.Pos(), "cannot assign to %s", id.Name)
+		}
+	}
+}
+
+// checkSelection checks that sel is exported by tab,
+// the package named by id.
+func (c *checker) checkSelection(id *ast.Ident, tab fun.Tab, sel *ast.Ident) {
+	switch {
+	case !sel.IsExported():
+		c.errorf(sel.Pos(), "name %s not exported by package %s", sel.Name, id.Name)
+	case !tab.Has(sel.Name):
+		c.errorf(sel.Pos(), "undefined: %s.%s", id.Name, sel.Name)
+	}
+}
@@ -0,0 +1,7 @@
+package cyclea
+
+import "cycleb"
+
+func F() {
+	cycleb.F()
+}
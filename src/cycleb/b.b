@@ -0,0 +1,7 @@
+package cycleb
+
+import "cyclea"
+
+func F() {
+	cyclea.F()
+}
@@ -0,0 +1,9 @@
+package greet
+
+func init() {
+	println("greet init")
+}
+
+func Hello(name) {
+	println("hello, " + name)
+}
@@ -3,3 +3,31 @@ package test
 func F() {
 	println("hello, test")
 }
+
+func f() {
+}
+
+type Option {
+	None
+	Some(x)
+}
+
+const Max = 10
+
+var Count = Max * 2
+
+func Twice(s string) string {
+	return s + s
+}
+
+type Stringer interface {
+	String() string
+}
+
+func (o Option) Or(def) {
+	switch o {
+	case Some(x):
+		return x
+	}
+	return def
+}
@@ -0,0 +1,1292 @@
+// Package types infers the type of every expression
+// in a Bubble program and reports type errors.
+//
+// Types are inferred as in Hindley-Milner type
+// inference, with package-level funcs generalized,
+// so a func such as
+//
+//	func first(l) {
+//		return l[0]
+//	}
+//
+// can be used with lists of any type.
+// Other names, including local funcs, have
+// a single type.
+//
+// The params and result of a func may be
+// annotated with their types, as in
+//
+//	func add(x int, y int) int
+//
+// An annotation only adds to what is inferred,
+// so _ in one stands for whatever type is.
+// The annotations of an exported func are part
+// of its type as seen by other packages.
+//
+// A few Go-like liberties are taken.
+// Untyped numeric literals may be ints or floats,
+// and + and < work on strings as well as numbers,
+// so type variables may be restricted to certain
+// kinds of type. Indexing, len, and slicing work
+// on more than one kind of type in the same way.
+// Records are typed by their fields, and a func
+// taking a record works on any record with the
+// fields it uses. The fields of data types are
+// inferred within the declaring package; to other
+// packages, a data type is generic in whichever
+// field types that package leaves open.
+//
+// An interface type is the type of records of
+// its methods. A value of a data type is used as
+// one by making the record of the methods it needs,
+// so a data type satisfies any interface whose
+// methods it has, as in Go. This happens where the
+// record type is known when the value is used:
+// passed to a func, assigned, returned, put in a
+// list or map literal, or converted with I(x).
+//
+// Like package sem, this runs before conversion to
+// the functional language in package fun, so that
+// errors can be reported with their source positions.
+package types
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/kr/bubble/ast"
+)
+
+type objKind int
+
+const (
+	builtinObj objKind = iota
+	pkgObj
+	funcObj
+	varObj
+	conObj
+	constObj
+	typeObj
+)
+
+// An object is something a name can refer to.
+type object struct {
+	kind objKind
+	typ  Type // a scheme for builtins, funcs, cons, consts, and types
+	tab  Tab  // for pkgObj
+
+	// for a const whose type is not yet known
+	x    ast.Expr
+	iota int
+	s    *scope
+	busy bool
+}
+
+// A Tab holds the types of the names
+// a package exports.
+type Tab struct {
+	name string
+	objs map[string]*object
+}
+
+// Type returns whether name is a data type
+// exported by the package.
+func (t Tab) Type(name string) bool {
+	obj := t.objs[name]
+	return obj != nil && obj.kind == typeObj
+}
+
+// TypeNames returns the names of the data types
+// exported by the package.
+func (t Tab) TypeNames() []string {
+	var a []string
+	for name, obj := range t.objs {
+		if obj.kind == typeObj {
+			a = append(a, name)
+		}
+	}
+	return a
+}
+
+type scope struct {
+	outer *scope
+	objs  map[string]*object
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{outer, make(map[string]*object)}
+}
+
+// lookup returns the object bound to name
+// in s or any enclosing scope,
+// or nil if there is none.
+func (s *scope) lookup(name string) *object {
+	for ; s != nil; s = s.outer {
+		if obj := s.objs[name]; obj != nil {
+			return obj
+		}
+	}
+	return nil
+}
+
+func (s *scope) declare(id *ast.Ident, obj *object) {
+	if id.Name != "_" {
+		s.objs[id.Name] = obj
+	}
+}
+
+type checker struct {
+	fset   *token.FileSet
+	info   *Info
+	errors scanner.ErrorList
+	level  int
+	result Type // of the function being checked
+	nums   map[*ast.BasicLit]Type
+	cmps   []comparison
+}
+
+// A comparison is a use of values of type t
+// that compares them, as == and map keys do,
+// and the error to report at pos if they
+// are not comparable.
+type comparison struct {
+	pos    token.Pos
+	t      Type
+	format string
+}
+
+// Info holds what package fun needs to know
+// about method calls, interfaces, and numbers.
+type Info struct {
+	// Methods maps each selector x.M that
+	// selects a method to the method.
+	Methods map[*ast.SelectorExpr]*Method
+
+	// Conversions maps each value converted
+	// to an interface type to the methods
+	// in the record made of it, sorted by name.
+	Conversions map[ast.Expr][]*Method
+
+	// Numbers maps each numeric literal whose
+	// type is known to be Int or Float to it,
+	// so that 2.0 can be an int and 2 a float.
+	Numbers map[*ast.BasicLit]Basic
+}
+
+// bailout is panicked by errorf to abandon
+// checking the current declaration, whose
+// types may be left inconsistent.
+type bailout struct{}
+
+// Check infers the types in p, which must
+// have passed sem.Check, and returns the types
+// of the names it exports.
+// Function pkgtab must return the Tab
+// of any package imported by p.
+// The methods selected and the conversions to
+// interface types are recorded in info,
+// whose maps must not be nil.
+// If there are type errors, the returned error
+// is a scanner.ErrorList describing them,
+// at most one for each declaration.
+func Check(fset *token.FileSet, p *ast.Package, pkgtab func(importPath string) Tab, info *Info) (Tab, error) {
+	c := &checker{fset: fset, info: info, nums: make(map[*ast.BasicLit]Type)}
+	pkgScope := newScope(universe)
+	tab := Tab{p.Name, make(map[string]*object)}
+
+	// Each field of a data type has a single
+	// type, inferred from the whole package.
+	var decls []*DataDecl
+	var cons []*object
+	ifaces := make(map[string]*ast.TypeDecl)
+	for _, file := range p.Files {
+		for _, t := range file.Types {
+			if t.Interface.IsValid() {
+				ifaces[t.Name.Name] = t
+				pkgScope.declare(t.Name, &object{kind: typeObj, typ: &Record{Fields: make(map[string]Type)}})
+				continue
+			}
+			d := &DataDecl{Name: t.Name.Name, Methods: make(map[string]*Method)}
+			decls = append(decls, d)
+			pkgScope.declare(t.Name, &object{kind: typeObj, typ: &Data{DataDecl: d}})
+			for _, spec := range t.Cons {
+				obj := &object{kind: conObj, typ: &Data{DataDecl: d}}
+				if len(spec.Params) > 0 {
+					f := &Func{Result: obj.typ}
+					for range spec.Params {
+						f.Params = append(f.Params, c.newVar())
+					}
+					obj.typ = f
+				}
+				cons = append(cons, obj)
+				pkgScope.declare(spec.Name, obj)
+			}
+		}
+	}
+
+	var funcs []*ast.FuncDecl
+	var funcObjs []*object
+	var funcScopes []*scope
+	var vars []*ast.ValueSpec
+	fileScopes := make(map[*ast.File]*scope)
+	specScopes := make(map[*ast.ValueSpec]*scope)
+	for _, file := range p.Files {
+		fs := newScope(pkgScope)
+		fileScopes[file] = fs
+		for _, f := range file.Funcs {
+			obj := &object{kind: funcObj}
+			if f.Name.Name != "init" {
+				pkgScope.declare(f.Name, obj)
+			}
+			funcs = append(funcs, f)
+			funcObjs = append(funcObjs, obj)
+			funcScopes = append(funcScopes, fs)
+		}
+		for _, f := range file.Methods {
+			obj := &object{kind: funcObj}
+			if t := pkgScope.objs[f.RecvType.Name]; t != nil && t.kind == typeObj {
+				if d, ok := t.typ.(*Data); ok {
+					d.Methods[f.Name.Name] = &Method{f.Name.Name, f, obj}
+				}
+			}
+			funcs = append(funcs, f)
+			funcObjs = append(funcObjs, obj)
+			funcScopes = append(funcScopes, fs)
+		}
+		for _, d := range file.Values {
+			var last []ast.Expr // values repeated for a const spec without any
+			for i, spec := range d.Specs {
+				specScopes[spec] = fs
+				if d.Tok == token.VAR {
+					for _, id := range spec.Names {
+						pkgScope.declare(id, &object{kind: varObj, typ: c.newVar()})
+					}
+					vars = append(vars, spec)
+					continue
+				}
+				if len(spec.Values) > 0 {
+					last = spec.Values
+				}
+				for j, id := range spec.Names {
+					if j < len(last) {
+						pkgScope.declare(id, &object{kind: constObj, x: last[j], iota: i, s: fs})
+					}
+				}
+			}
+		}
+	}
+	for _, file := range p.Files {
+		bindImports(fileScopes[file], file.Imports, pkgtab)
+	}
+
+	// An interface is filled in after those
+	// its methods refer to, which package sem
+	// ensures do not refer back to it.
+	filled := make(map[*ast.TypeDecl]bool)
+	var fill func(t *ast.TypeDecl, s *scope)
+	fill = func(t *ast.TypeDecl, s *scope) {
+		if filled[t] {
+			return
+		}
+		filled[t] = true
+		r := pkgScope.objs[t.Name.Name].typ.(*Record)
+		for _, m := range t.Methods {
+			ast.Inspect(m.Value, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok && ifaces[id.Name] != nil {
+					fill(ifaces[id.Name], s)
+				}
+				return true
+			})
+			r.Fields[m.Name.Name] = c.typeExpr(m.Value, s)
+		}
+	}
+	for _, file := range p.Files {
+		for _, t := range file.Types {
+			if t.Interface.IsValid() {
+				fill(t, fileScopes[file])
+			}
+		}
+	}
+
+	// Funcs are checked in groups that refer to
+	// one another, each after the groups it refers to,
+	// and generalized when their group is done.
+	for _, group := range funcGroups(funcs) {
+		c.level++
+		for _, i := range group {
+			f := funcs[i]
+			params, types := signature(f)
+			funcObjs[i].typ = c.funcType(params, types, f.Ellipsis.IsValid(), f.Result, funcScopes[i])
+		}
+		for _, i := range group {
+			f := funcs[i]
+			params, _ := signature(f)
+			c.catch(func() {
+				c.funcBody(params, funcObjs[i].typ.(*Func), f.Body, funcScopes[i])
+			})
+		}
+		c.level--
+		for _, i := range group {
+			c.generalize(funcObjs[i].typ)
+		}
+	}
+
+	for _, spec := range vars {
+		spec := spec
+		s := specScopes[spec]
+		c.catch(func() {
+			var lhs []Type
+			for _, id := range spec.Names {
+				if id.Name == "_" {
+					lhs = append(lhs, c.newVar())
+				} else {
+					lhs = append(lhs, pkgScope.objs[id.Name].typ)
+				}
+			}
+			c.assign(spec.Pos(), lhs, spec.Values, s)
+		})
+	}
+
+	// A data type is generic in the types of its
+	// fields that are still unknown.
+	for _, d := range decls {
+		for _, obj := range cons {
+			if f, ok := obj.typ.(*Func); ok && resolve(f.Result).(*Data).DataDecl == d {
+				for _, x := range f.Params {
+					walk(x, func(v *Var) {
+						if v.level != generic {
+							v.level = generic
+							d.Params = append(d.Params, v)
+						}
+					})
+				}
+			}
+		}
+		for _, m := range d.Methods {
+			m.obj.typ = withParams(m.obj.typ)
+		}
+	}
+	for name, obj := range pkgScope.objs {
+		if !ast.IsExported(name) {
+			continue
+		}
+		switch obj.kind {
+		case constObj:
+			c.catch(func() { c.constType(obj) })
+		case varObj:
+			// a single variable, so not generic
+			tab.objs[name] = obj
+			continue
+		}
+		tab.objs[name] = &object{kind: obj.kind, typ: withParams(obj.typ)}
+	}
+
+	c.numbers()
+	c.comparisons()
+	c.errors.Sort()
+	return tab, c.errors.Err()
+}
+
+// bindImports binds the names of the
+// imported packages in s.
+func bindImports(s *scope, imports []*ast.ImportSpec, pkgtab func(importPath string) Tab) {
+	for _, spec := range imports {
+		tab := pkgtab(spec.Path.String())
+		name := tab.name
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		switch name {
+		case "_":
+		case ".":
+			for n, obj := range tab.objs {
+				s.objs[n] = obj
+			}
+		default:
+			s.objs[name] = &object{kind: pkgObj, tab: tab}
+		}
+	}
+}
+
+// signature returns the params of f and
+// their types, with the receiver first
+// and its type if f is a method.
+func signature(f *ast.FuncDecl) ([]*ast.Ident, []ast.Expr) {
+	if f.Recv == nil {
+		return f.Params, f.Types
+	}
+	types := make([]ast.Expr, len(f.Params)+1)
+	types[0] = f.RecvType
+	if f.Types != nil {
+		copy(types[1:], f.Types)
+	}
+	return append([]*ast.Ident{f.Recv}, f.Params...), types
+}
+
+// funcGroups returns the indexes of funcs and methods
+// grouped into strongly connected components of the
+// graph of which refer to which, each group after all
+// those it refers to. A name is taken to refer to
+// a func or method if it is its name, even if it
+// is shadowed or selects something else;
+// that only puts more funcs in the same group.
+func funcGroups(funcs []*ast.FuncDecl) [][]int {
+	index := make(map[string][]int)
+	for i, f := range funcs {
+		if f.Recv != nil || f.Name.Name != "init" {
+			index[f.Name.Name] = append(index[f.Name.Name], i)
+		}
+	}
+	edges := make([][]int, len(funcs))
+	for i, f := range funcs {
+		ast.Inspect(f.Body, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok {
+				edges[i] = append(edges[i], index[id.Name]...)
+			}
+			return true
+		})
+	}
+
+	// Tarjan's algorithm
+	var groups [][]int
+	var stack []int
+	num := make([]int, len(funcs)) // 0 means not yet visited
+	low := make([]int, len(funcs))
+	onStack := make([]bool, len(funcs))
+	n := 0
+	var visit func(i int)
+	visit = func(i int) {
+		n++
+		num[i], low[i] = n, n
+		stack = append(stack, i)
+		onStack[i] = true
+		for _, j := range edges[i] {
+			if num[j] == 0 {
+				visit(j)
+				if low[j] < low[i] {
+					low[i] = low[j]
+				}
+			} else if onStack[j] && num[j] < low[i] {
+				low[i] = num[j]
+			}
+		}
+		if low[i] == num[i] {
+			var g []int
+			for {
+				j := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[j] = false
+				g = append(g, j)
+				if j == i {
+					break
+				}
+			}
+			groups = append(groups, g)
+		}
+	}
+	// Methods are visited first, so each is checked
+	// before any func that does not refer to it but
+	// may use it in converting to an interface.
+	for _, method := range []bool{true, false} {
+		for i, f := range funcs {
+			if num[i] == 0 && (f.Recv != nil) == method {
+				visit(i)
+			}
+		}
+	}
+	return groups
+}
+
+// withParams returns t with each data type
+// declared in the package just checked
+// given its params as arguments.
+func withParams(t Type) Type {
+	switch t := resolve(t).(type) {
+	case *List:
+		return &List{withParams(t.Elem)}
+	case *Map:
+		return &Map{withParams(t.Key), withParams(t.Elem)}
+	case *Func:
+		f := &Func{Variadic: t.Variadic, Result: withParams(t.Result)}
+		for _, x := range t.Params {
+			f.Params = append(f.Params, withParams(x))
+		}
+		return f
+	case Tuple:
+		var u Tuple
+		for _, x := range t {
+			u = append(u, withParams(x))
+		}
+		return u
+	case *Record:
+		m, rest := fields(t)
+		r := &Record{Fields: make(map[string]Type), Rest: rest}
+		for name, x := range m {
+			r.Fields[name] = withParams(x)
+		}
+		return r
+	case *Data:
+		d := &Data{DataDecl: t.DataDecl, Args: t.Args}
+		if d.Args == nil {
+			for _, v := range t.Params {
+				d.Args = append(d.Args, v)
+			}
+		}
+		return d
+	}
+	return t
+}
+
+func (c *checker) errorf(pos token.Pos, format string, v ...interface{}) {
+	c.errors.Add(c.fset.Position(pos), fmt.Sprintf(format, v...))
+	panic(bailout{})
+}
+
+// catch calls f, recovering from a bailout.
+func (c *checker) catch(f func()) {
+	level := c.level
+	defer func() {
+		if v := recover(); v != nil {
+			if _, ok := v.(bailout); !ok {
+				panic(v)
+			}
+			c.level = level
+		}
+	}()
+	f()
+}
+
+// unifyf unifies t and u, or reports an error
+// at pos, formatting the types with format.
+func (c *checker) unifyf(pos token.Pos, t, u Type, format string) {
+	if !c.unify(t, u) {
+		s := typeStrings(t, u)
+		c.errorf(pos, format, s[0], s[1])
+	}
+}
+
+// constType returns the type of const obj.
+func (c *checker) constType(obj *object) Type {
+	if obj.typ != nil {
+		return obj.typ
+	}
+	if obj.busy {
+		// a cycle, reported by package fun
+		return c.newVar()
+	}
+	obj.busy = true
+	s := newScope(obj.s)
+	s.objs["iota"] = &object{kind: constObj, typ: &Var{kinds: num, level: generic}}
+	c.level++
+	t := c.expr(obj.x, s)
+	c.level--
+	c.generalize(t)
+	obj.typ = t
+	return t
+}
+
+// typeOf returns the type of a use of obj.
+func (c *checker) typeOf(obj *object) Type {
+	switch {
+	case obj == nil:
+		// undefined, as reported by package sem
+		return c.newVar()
+	case obj.kind == constObj:
+		return c.instantiate(c.constType(obj))
+	case obj.typ == nil:
+		// println, or a func in a group whose
+		// checking was abandoned
+		return c.newVar()
+	}
+	return c.instantiate(obj.typ)
+}
+
+// funcType returns a new type for a func with the
+// given params, annotated with types, if not nil,
+// and result, if not nil.
+func (c *checker) funcType(params []*ast.Ident, types []ast.Expr, variadic bool, result ast.Expr, s *scope) *Func {
+	f := &Func{Variadic: variadic, Result: c.newVar()}
+	for i := range params {
+		var t Type = c.newVar()
+		if types != nil && types[i] != nil {
+			t = c.typeExpr(types[i], s)
+		}
+		f.Params = append(f.Params, t)
+	}
+	if variadic {
+		n := len(f.Params) - 1
+		f.Params[n] = &List{f.Params[n]}
+	}
+	if result != nil {
+		f.Result = c.typeExpr(result, s)
+	}
+	return f
+}
+
+// typeExpr returns the type annotation x stands for.
+// Each _ in it is a new Var.
+func (c *checker) typeExpr(x ast.Expr, s *scope) Type {
+	switch x := x.(type) {
+	case *ast.Ident:
+		obj := s.lookup(x.Name)
+		switch {
+		case obj == nil:
+			// _, or undefined, as reported by package sem
+			return c.newVar()
+		case obj.kind == typeObj:
+			return c.instantiate(obj.typ)
+		}
+		switch x.Name {
+		case "int":
+			return Int
+		case "float":
+			return Float
+		case "string":
+			return String
+		}
+		return c.newVar()
+	case *ast.SelectorExpr:
+		obj := s.lookup(x.X.(*ast.Ident).Name)
+		if obj != nil && obj.kind == pkgObj {
+			if obj := obj.tab.objs[x.Sel.Name]; obj != nil && obj.kind == typeObj {
+				return c.instantiate(obj.typ)
+			}
+		}
+		return c.newVar()
+	case *ast.ListType:
+		return &List{c.typeExpr(x.Elem, s)}
+	case *ast.MapType:
+		return &Map{c.typeExpr(x.Key, s), c.typeExpr(x.Value, s)}
+	case *ast.FuncType:
+		f := &Func{Variadic: x.Ellipsis.IsValid(), Result: c.newVar()}
+		for _, p := range x.Params {
+			f.Params = append(f.Params, c.typeExpr(p, s))
+		}
+		if f.Variadic {
+			n := len(f.Params) - 1
+			f.Params[n] = &List{f.Params[n]}
+		}
+		if x.Result != nil {
+			f.Result = c.typeExpr(x.Result, s)
+		}
+		return f
+	case *ast.RecordType:
+		r := &Record{Fields: make(map[string]Type)}
+		for _, f := range x.Fields {
+			r.Fields[f.Name.Name] = c.typeExpr(f.Value, s)
+		}
+		if x.Ellipsis.IsValid() {
+			r.Rest = &Var{row: true, level: c.level}
+		}
+		return r
+	case *ast.TupleType:
+		var t Tuple
+		for _, y := range x.Types {
+			t = append(t, c.typeExpr(y, s))
+		}
+		return t
+	}
+	return c.newVar()
+}
+
+// funcBody checks the body of a func of type f.
+// A func that ends without returning a value
+// returns 0, whatever type its result has.
+func (c *checker) funcBody(params []*ast.Ident, f *Func, body *ast.BlockStmt, s *scope) {
+	result := c.result
+	c.result = f.Result
+	s = newScope(s)
+	for i, p := range params {
+		s.declare(p, &object{kind: varObj, typ: f.Params[i]})
+	}
+	c.stmts(body.List, s)
+	c.result = result
+}
+
+func (c *checker) stmts(list []ast.Stmt, s *scope) {
+	for _, stmt := range list {
+		c.stmt(stmt, s)
+	}
+}
+
+func (c *checker) stmt(node ast.Stmt, s *scope) {
+	switch node := node.(type) {
+	case *ast.ExprStmt:
+		c.expr(node.X, s)
+	case *ast.DeferStmt:
+		c.expr(node.Call, s)
+	case *ast.BlockStmt:
+		c.stmts(node.List, newScope(s))
+	case *ast.IfStmt:
+		c.cond(node.Cond, "if", s)
+		c.stmt(node.Body, s)
+		if node.Else != nil {
+			c.stmt(node.Else, s)
+		}
+	case *ast.ForStmt:
+		if node.X != nil {
+			c.rangeStmt(node, s)
+			break
+		}
+		s = newScope(s)
+		if node.Init != nil {
+			c.stmt(node.Init, s)
+		}
+		if node.Cond != nil {
+			c.cond(node.Cond, "for", s)
+		}
+		if node.Post != nil {
+			c.stmt(node.Post, s)
+		}
+		c.stmt(node.Body, s)
+	case *ast.SwitchStmt:
+		c.switchStmt(node, s)
+	case *ast.LabeledStmt:
+		c.stmt(node.Stmt, s)
+	case *ast.BranchStmt:
+	case *ast.ReturnStmt:
+		switch len(node.Results) {
+		case 0:
+		case 1:
+			x := node.Results[0]
+			c.convert(x, c.expr(x, s), c.result, "cannot use %s as %s in return statement")
+		default:
+			var t Tuple
+			for _, x := range node.Results {
+				t = append(t, c.expr(x, s))
+			}
+			c.unifyf(node.Pos(), t, c.result, "cannot use %s as %s in return statement")
+		}
+	case *ast.IncDecStmt:
+		t := c.lhs(node.X, s)
+		if !c.unify(t, c.newKindVar(num)) {
+			c.errorf(node.TokPos, "invalid operation: operator %s not defined on %s", node.Tok, TypeString(t))
+		}
+	case *ast.AssignStmt:
+		if node.Tok == token.ASSIGN {
+			var lhs []Type
+			for _, x := range node.Lhs {
+				lhs = append(lhs, c.lhs(x, s))
+			}
+			c.assign(node.Pos(), lhs, node.Rhs, s)
+			break
+		}
+		// Names already declared in s are assigned,
+		// and the others declared.
+		var lhs []Type
+		var decl []int
+		for i, x := range node.Lhs {
+			if obj := s.objs[x.(*ast.Ident).Name]; obj != nil {
+				lhs = append(lhs, obj.typ)
+				continue
+			}
+			lhs = append(lhs, c.newVar())
+			decl = append(decl, i)
+		}
+		c.assign(node.Pos(), lhs, node.Rhs, s)
+		for _, i := range decl {
+			s.declare(node.Lhs[i].(*ast.Ident), &object{kind: varObj, typ: lhs[i]})
+		}
+	default:
+		c.errorf(node.Pos(), "unhandled %T", node)
+	}
+}
+
+// assign checks the assignment of the values of rhs
+// to operands of the types in lhs.
+func (c *checker) assign(pos token.Pos, lhs []Type, rhs []ast.Expr, s *scope) {
+	if len(rhs) == len(lhs) {
+		for i, x := range rhs {
+			c.convert(x, c.expr(x, s), lhs[i], "cannot use %s as %s in assignment")
+		}
+		return
+	}
+
+	// a single value with several results
+	var t Type
+	if x, ok := rhs[0].(*ast.IndexExpr); ok && len(lhs) == 2 {
+		// v, ok := m[k]
+		key, elem := c.newVar(), c.newVar()
+		if !c.unify(c.expr(x.X, s), &Map{key, elem}) {
+			c.errorf(pos, "assignment mismatch: 2 variables but 1 value")
+		}
+		c.unifyf(x.Index.Pos(), c.expr(x.Index, s), key, "cannot use %s as %s in map index")
+		t = Tuple{elem, Int}
+	} else {
+		t = c.expr(rhs[0], s)
+	}
+	want := make(Tuple, len(lhs))
+	for i := range want {
+		want[i] = c.newVar()
+	}
+	if !c.unify(t, want) {
+		if r, ok := resolve(t).(Tuple); ok {
+			c.errorf(pos, "assignment mismatch: %d variables but %d values", len(lhs), len(r))
+		}
+		c.errorf(pos, "assignment mismatch: %d variables but 1 value", len(lhs))
+	}
+	for i := range want {
+		c.unifyf(pos, want[i], lhs[i], "cannot use %s as %s in assignment")
+	}
+}
+
+// lhs returns the type of x, an operand on the
+// left side of an assignment with = or an
+// increment or decrement. Strings are immutable,
+// so only an element of a list or map may be
+// assigned.
+func (c *checker) lhs(x ast.Expr, s *scope) Type {
+	switch x := x.(type) {
+	case *ast.Ident:
+		if x.Name != "_" {
+			return c.typeOf(s.lookup(x.Name))
+		}
+	case *ast.IndexExpr:
+		return c.indexOf(x, listKind|mapKind, "cannot assign to index of %s", s)
+	}
+	return c.newVar()
+}
+
+// rangeStmt checks range loop f.
+// Ranging over a string yields the byte index
+// and the value of each rune, over a list
+// the index and value of each element, and
+// over a map each key and its value.
+func (c *checker) rangeStmt(f *ast.ForStmt, s *scope) {
+	t := c.expr(f.X, s)
+	key, elem := c.newVar(), c.newVar()
+	if !c.unify(t, c.newIndexVar(key, elem)) {
+		c.errorf(f.X.Pos(), "cannot range over %s", TypeString(t))
+	}
+	s = newScope(s)
+	vars := []Type{key, elem}
+	for i, x := range []ast.Expr{f.Key, f.Value} {
+		switch {
+		case x == nil:
+		case f.Tok == token.DEFINE:
+			s.declare(x.(*ast.Ident), &object{kind: varObj, typ: vars[i]})
+		default:
+			c.unifyf(x.Pos(), vars[i], c.lhs(x, s), "cannot use %s as %s in range")
+		}
+	}
+	c.stmt(f.Body, s)
+}
+
+// cond checks x, the condition of an if or for statement.
+// Booleans are integers.
+func (c *checker) cond(x ast.Expr, stmt string, s *scope) {
+	if !c.unify(c.expr(x, s), Int) {
+		c.errorf(x.Pos(), "non-boolean condition in %s statement", stmt)
+	}
+}
+
+func (c *checker) switchStmt(sw *ast.SwitchStmt, s *scope) {
+	tag := c.expr(sw.Tag, s)
+	for _, cc := range sw.Cases {
+		cs := newScope(s)
+		for _, x := range cc.List {
+			if isIntPattern(x) {
+				if !c.unify(Int, tag) {
+					c.errorf(x.Pos(), "invalid case: mismatched types int and %s", TypeString(tag))
+				}
+				continue
+			}
+			c.pattern(x, tag, cs, s)
+		}
+		c.stmts(cc.Body, cs)
+	}
+}
+
+// numbers records the type of each numeric literal
+// in c.info. A number whose type is still open,
+// unless it is generic, gets the default type of
+// the first literal of that type, in source order:
+// an int, or a float for a float literal, as for
+// an untyped constant in Go.
+func (c *checker) numbers() {
+	var lits []*ast.BasicLit
+	for x := range c.nums {
+		lits = append(lits, x)
+	}
+	sort.Slice(lits, func(i, j int) bool {
+		return lits[i].Pos() < lits[j].Pos()
+	})
+	for _, x := range lits {
+		t := resolve(c.nums[x])
+		if v, ok := t.(*Var); ok && v.level != generic {
+			t = Int
+			if x.Kind == token.FLOAT {
+				t = Float
+			}
+			c.bind(v, t)
+		}
+		if t, ok := resolve(t).(Basic); ok {
+			c.info.Numbers[x] = t
+		}
+	}
+}
+
+// isIntPattern returns whether x is an integer
+// or rune literal, possibly negated, which package
+// fun takes as an integer case in a switch.
+func isIntPattern(x ast.Expr) bool {
+	if u, ok := x.(*ast.UnaryExpr); ok && u.Op == token.SUB {
+		x = u.X
+	}
+	lit, ok := x.(*ast.BasicLit)
+	return ok && (lit.Kind == token.INT || lit.Kind == token.CHAR)
+}
+
+// pattern checks x, a constructor pattern in a case
+// of a switch on a value of type tag, and declares
+// its bindings in cs.
+func (c *checker) pattern(x ast.Expr, tag Type, cs, s *scope) {
+	fn := x
+	var params []ast.Expr
+	if call, ok := x.(*ast.CallExpr); ok {
+		fn, params = call.Fun, call.Args
+	}
+	t := c.expr(fn, s)
+	if f, ok := t.(*Func); ok {
+		t = f.Result
+		for i, p := range params {
+			cs.declare(p.(*ast.Ident), &object{kind: varObj, typ: f.Params[i]})
+		}
+	}
+	if !c.unify(t, tag) {
+		ts := typeStrings(t, tag)
+		c.errorf(x.Pos(), "invalid case: mismatched types %s and %s", ts[0], ts[1])
+	}
+}
+
+func (c *checker) expr(x ast.Expr, s *scope) Type {
+	switch x := x.(type) {
+	case *ast.Ident:
+		return c.typeOf(s.lookup(x.Name))
+	case *ast.BasicLit:
+		switch x.Kind {
+		case token.STRING:
+			return String
+		case token.FLOAT:
+			// A float literal with an integer value
+			// may be an int, as in Go.
+			f, err := strconv.ParseFloat(x.Value, 64)
+			if err != nil {
+				c.errorf(x.Pos(), "constant %s overflows float", x.Value)
+			}
+			if f != math.Trunc(f) {
+				return Float
+			}
+		}
+		t := c.newKindVar(num)
+		c.nums[x] = t
+		return t
+	case *ast.CallExpr:
+		return c.call(x, s)
+	case *ast.BinaryExpr:
+		return c.binary(x, s)
+	case *ast.UnaryExpr:
+		t := c.expr(x.X, s)
+		var want Type = Int
+		if x.Op == token.SUB {
+			want = c.newKindVar(num)
+		}
+		if !c.unify(t, want) {
+			c.errorf(x.OpPos, "invalid operation: operator %s not defined on %s", x.Op, TypeString(t))
+		}
+		return t
+	case *ast.ListLit:
+		elem := c.newVar()
+		for _, e := range x.Elts {
+			c.convert(e, c.expr(e, s), elem, "cannot use %s as %s in list literal")
+		}
+		return &List{elem}
+	case *ast.MapLit:
+		key, elem := c.newVar(), c.newVar()
+		for _, kv := range x.Elts {
+			c.unifyf(kv.Key.Pos(), c.expr(kv.Key, s), key, "cannot use %s as %s in map literal")
+			c.convert(kv.Value, c.expr(kv.Value, s), elem, "cannot use %s as %s in map literal")
+		}
+		c.cmps = append(c.cmps, comparison{x.Pos(), key, "invalid map key type %s"})
+		return &Map{key, elem}
+	case *ast.RecordLit:
+		r := &Record{Fields: make(map[string]Type)}
+		for _, f := range x.Fields {
+			r.Fields[f.Name.Name] = c.expr(f.Value, s)
+		}
+		return r
+	case *ast.IndexExpr:
+		return c.index(x, s)
+	case *ast.SliceExpr:
+		t := c.expr(x.X, s)
+		if !c.unify(t, c.newKindVar(sliced)) {
+			c.errorf(x.Lbrack, "invalid operation: cannot slice %s", TypeString(t))
+		}
+		for _, b := range []ast.Expr{x.Low, x.High} {
+			if b != nil {
+				c.unifyf(b.Pos(), c.expr(b, s), Int, "cannot use %s as %s in slice index")
+			}
+		}
+		return t
+	case *ast.SelectorExpr:
+		if id, ok := x.X.(*ast.Ident); ok {
+			if obj := s.lookup(id.Name); obj != nil && obj.kind == pkgObj {
+				return c.typeOf(obj.tab.objs[x.Sel.Name])
+			}
+		}
+		t := c.expr(x.X, s)
+		if _, ok := resolve(t).(*Data); ok {
+			return c.method(x, t)
+		}
+		return c.field(t, x.Sel)
+	case *ast.FuncLit:
+		f := c.funcType(x.Params, x.Types, x.Ellipsis.IsValid(), x.Result, s)
+		c.funcBody(x.Params, f, x.Body, s)
+		return f
+	case *ast.ShortFuncLit:
+		// It takes as many params as it uses of x, y, and z.
+		f := &Func{}
+		fs := newScope(s)
+		for _, p := range x.Params() {
+			t := c.newVar()
+			f.Params = append(f.Params, t)
+			fs.objs[p.Name] = &object{kind: varObj, typ: t}
+		}
+		f.Result = c.expr(x.Body, fs)
+		return f
+	}
+	c.errorf(x.Pos(), "unhandled %T", x)
+	panic("unreached")
+}
+
+// field returns the type of field sel
+// of a record of type t.
+func (c *checker) field(t Type, sel *ast.Ident) Type {
+	if r, ok := resolve(t).(*Record); ok {
+		m, rest := fields(r)
+		if ft, ok := m[sel.Name]; ok {
+			return ft
+		}
+		if rest == nil {
+			c.errorf(sel.Pos(), "record has no field %s", sel.Name)
+		}
+	}
+	ft := c.newVar()
+	r := &Record{map[string]Type{sel.Name: ft}, &Var{row: true, level: c.level}}
+	if !c.unify(t, r) {
+		c.errorf(sel.Pos(), "%s has no field %s", TypeString(t), sel.Name)
+	}
+	return ft
+}
+
+// method returns the type of method value x,
+// a selection from a value of data type t.
+func (c *checker) method(x *ast.SelectorExpr, t Type) Type {
+	m := resolve(t).(*Data).Methods[x.Sel.Name]
+	if m == nil {
+		c.errorf(x.Sel.Pos(), "%s has no field or method %s", TypeString(t), x.Sel.Name)
+	}
+	c.info.Methods[x] = m
+	return c.bound(m, t, x.Pos())
+}
+
+// bound returns the type of method m
+// bound to a receiver of type t.
+func (c *checker) bound(m *Method, t Type, pos token.Pos) Type {
+	f, ok := c.typeOf(m.obj).(*Func)
+	if !ok {
+		// in a group whose checking was abandoned
+		return c.newVar()
+	}
+	c.unifyf(pos, t, f.Params[0], "cannot use %s as %s in method receiver")
+	return &Func{Params: f.Params[1:], Variadic: f.Variadic, Result: f.Result}
+}
+
+// convert checks the use of x, of type t, as a value
+// of type target, or reports an error at x, formatting
+// the types with format. A value of a data type used
+// as a record is converted to the record of its
+// methods the record type has, and a record type
+// that may have other fields is closed.
+func (c *checker) convert(x ast.Expr, t, target Type, format string) {
+	d, ok := resolve(t).(*Data)
+	r, isRecord := resolve(target).(*Record)
+	if !ok || !isRecord {
+		c.unifyf(x.Pos(), t, target, format)
+		return
+	}
+	m, rest := fields(r)
+	var methods []*Method
+	for _, name := range sortedNames(m) {
+		method := d.Methods[name]
+		if method == nil {
+			s := typeStrings(t, target)
+			c.errorf(x.Pos(), format+" (missing method %s)", s[0], s[1], name)
+		}
+		if !c.unify(c.bound(method, t, x.Pos()), m[name]) {
+			s := typeStrings(t, target)
+			c.errorf(x.Pos(), format+" (wrong type for method %s)", s[0], s[1], name)
+		}
+		methods = append(methods, method)
+	}
+	if rest != nil {
+		c.bindRow(rest, &Record{Fields: make(map[string]Type)})
+	}
+	c.info.Conversions[x] = methods
+}
+
+// typeName returns the object for the type x names,
+// or nil if x does not name a type.
+func typeName(x ast.Expr, s *scope) *object {
+	var obj *object
+	switch x := x.(type) {
+	case *ast.Ident:
+		obj = s.lookup(x.Name)
+	case *ast.SelectorExpr:
+		if id, ok := x.X.(*ast.Ident); ok {
+			if p := s.lookup(id.Name); p != nil && p.kind == pkgObj {
+				obj = p.tab.objs[x.Sel.Name]
+			}
+		}
+	}
+	if obj == nil || obj.kind != typeObj {
+		return nil
+	}
+	return obj
+}
+
+func (c *checker) index(x *ast.IndexExpr, s *scope) Type {
+	return c.indexOf(x, sized, "invalid operation: cannot index %s", s)
+}
+
+// indexOf returns the type of x, which must index
+// a value of one of kinds k, else the error is format.
+func (c *checker) indexOf(x *ast.IndexExpr, k kinds, format string, s *scope) Type {
+	t := c.expr(x.X, s)
+	key, elem := c.newVar(), c.newVar()
+	v := c.newIndexVar(key, elem)
+	v.kinds = k
+	if !c.unify(t, v) {
+		c.errorf(x.Lbrack, format, TypeString(t))
+	}
+	c.unifyf(x.Index.Pos(), c.expr(x.Index, s), key, "cannot use %s as %s in index")
+	return elem
+}
+
+func (c *checker) call(x *ast.CallExpr, s *scope) Type {
+	if id, ok := x.Fun.(*ast.Ident); ok && s.lookup(id.Name) == universe.objs["println"] {
+		// println takes values of any type
+		for _, a := range x.Args {
+			c.expr(a, s)
+		}
+		return c.newVar()
+	}
+	if obj := typeName(x.Fun, s); obj != nil {
+		// a conversion I(x), which package sem
+		// ensures has a single argument
+		target := c.instantiate(obj.typ)
+		if _, ok := target.(*Record); !ok {
+			c.errorf(x.Pos(), "cannot convert to non-interface type %s", TypeString(target))
+		}
+		c.convert(x.Args[0], c.expr(x.Args[0], s), target, "cannot convert %s to %s")
+		return target
+	}
+	ft := c.expr(x.Fun, s)
+	var args []Type
+	for _, a := range x.Args {
+		args = append(args, c.expr(a, s))
+	}
+	f, ok := resolve(ft).(*Func)
+	if !ok {
+		f = &Func{Params: args, Result: c.newVar()}
+		if !c.unify(ft, f) {
+			if v, ok := resolve(ft).(*Var); ok && v.kinds == 0 {
+				ts := typeStrings(ft, f)
+				c.errorf(x.Pos(), "cannot use %s as %s: infinite type", ts[0], ts[1])
+			}
+			c.errorf(x.Pos(), "invalid operation: cannot call non-function of type %s", TypeString(ft))
+		}
+		return f.Result
+	}
+	n := len(f.Params)
+	switch {
+	case f.Variadic && len(args) < n-1:
+		c.errorf(x.Pos(), "wrong number of arguments%s: have %d, want at least %d", callee(x), len(args), n-1)
+	case !f.Variadic && len(args) != n:
+		c.errorf(x.Pos(), "wrong number of arguments%s: have %d, want %d", callee(x), len(args), n)
+	}
+	for i, a := range args {
+		var p Type
+		if f.Variadic && i >= n-1 {
+			p = resolve(f.Params[n-1]).(*List).Elem
+		} else {
+			p = f.Params[i]
+		}
+		c.convert(x.Args[i], a, p, "cannot use %s as %s in argument")
+	}
+	return f.Result
+}
+
+// callee returns " to f" if call x calls
+// a function by its name f, or else "".
+func callee(x *ast.CallExpr) string {
+	switch f := x.Fun.(type) {
+	case *ast.Ident:
+		return " to " + f.Name
+	case *ast.SelectorExpr:
+		return " to " + f.Sel.Name
+	}
+	return ""
+}
+
+func (c *checker) binary(x *ast.BinaryExpr, s *scope) Type {
+	t, u := c.expr(x.X, s), c.expr(x.Y, s)
+	switch x.Op {
+	case token.LAND, token.LOR:
+		for _, v := range []Type{t, u} {
+			if !c.unify(v, Int) {
+				c.errorf(x.OpPos, "invalid operation: operator %s not defined on %s", x.Op, TypeString(v))
+			}
+		}
+		return Int
+	}
+	if !c.unify(t, u) {
+		ts := typeStrings(t, u)
+		c.errorf(x.OpPos, "invalid operation: mismatched types %s and %s", ts[0], ts[1])
+	}
+	var k kinds
+	switch x.Op {
+	case token.ADD, token.LSS, token.LEQ, token.GTR, token.GEQ:
+		k = ordered
+	case token.SUB, token.MUL, token.QUO, token.REM:
+		k = num
+	}
+	if k != 0 && !c.unify(t, c.newKindVar(k)) {
+		c.errorf(x.OpPos, "invalid operation: operator %s not defined on %s", x.Op, TypeString(t))
+	}
+	if x.Op == token.EQL || x.Op == token.NEQ {
+		c.cmps = append(c.cmps, comparison{x.OpPos, t, "invalid operation: operator " + x.Op.String() + " not defined on %s"})
+	}
+	switch x.Op {
+	case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+		return Int
+	}
+	return t
+}
+
+// comparisons reports each comparison of values
+// that are not comparable. The types are not known
+// until the whole package is checked.
+func (c *checker) comparisons() {
+	for _, cmp := range c.cmps {
+		c.catch(func() {
+			if !comparable(cmp.t) {
+				c.errorf(cmp.pos, cmp.format, TypeString(cmp.t))
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package types
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/kr/bubble/ast"
+)
+
+// A Type is the type of a Bubble value.
+type Type interface {
+	typ()
+}
+
+// A Basic is one of the basic types.
+// Booleans are integers, as they are at run time.
+// Any is the type of the value of a panic,
+// which may be of any type, boxed so that
+// it has the same type whatever it was.
+type Basic int
+
+const (
+	Int Basic = iota
+	Float
+	String
+	Any
+)
+
+// A List is the type of lists of Elem.
+type List struct {
+	Elem Type
+}
+
+// A Map is the type of maps from Key to Elem.
+type Map struct {
+	Key, Elem Type
+}
+
+// A Func is the type of a function.
+// If Variadic is set, the last param is a List,
+// and the function takes any number of its elements
+// in its place.
+type Func struct {
+	Params   []Type
+	Variadic bool
+	Result   Type
+}
+
+// A Tuple is the type of several values
+// returned at once.
+type Tuple []Type
+
+// A Record is the type of records with the
+// given fields, and, if Rest is not nil,
+// whatever fields the row variable Rest
+// stands for.
+type Record struct {
+	Fields map[string]Type
+	Rest   *Var
+}
+
+// A Data is a data type declared with type.
+// Its type arguments are the types of those
+// of its constructors' fields that are not
+// fixed by the package declaring it.
+type Data struct {
+	*DataDecl
+	Args []Type
+}
+
+// A DataDecl is the declaration of a data type.
+type DataDecl struct {
+	Name    string
+	Params  []*Var // set once its package is checked
+	Methods map[string]*Method
+}
+
+// A Method is a method of a data type.
+// Its type is that of a func taking
+// the receiver as its first param.
+type Method struct {
+	Name string
+	Decl *ast.FuncDecl
+	obj  *object
+}
+
+// A Var is a type variable. Once bound to a type,
+// it is the same as that type.
+//
+// An unbound Var may be restricted to types of
+// certain kinds. If so, and it has a key and elem,
+// it may only stand for a type that can be indexed
+// by key, giving elem: a List, a String, or a Map.
+type Var struct {
+	ref   Type
+	kinds kinds // 0 means any kind
+	key   Type
+	elem  Type
+	row   bool // stands for the fields of a Record
+	level int  // see generalize
+}
+
+func (Basic) typ()   {}
+func (*List) typ()   {}
+func (*Map) typ()    {}
+func (*Func) typ()   {}
+func (Tuple) typ()   {}
+func (*Record) typ() {}
+func (*Data) typ()   {}
+func (*Var) typ()    {}
+
+// A kinds is a set of kinds of type,
+// each kind a bit.
+type kinds uint
+
+const (
+	intKind kinds = 1 << iota
+	floatKind
+	stringKind
+	listKind
+	mapKind
+)
+
+// The sets of kinds the operators accept.
+const (
+	num     = intKind | floatKind
+	ordered = num | stringKind // for +, <, and so on
+	sized   = stringKind | listKind | mapKind
+	sliced  = stringKind | listKind
+)
+
+// kind returns the kind of t,
+// or 0 if t is of none of the kinds above.
+func kind(t Type) kinds {
+	switch t := t.(type) {
+	case Basic:
+		switch t {
+		case Int:
+			return intKind
+		case Float:
+			return floatKind
+		case String:
+			return stringKind
+		}
+	case *List:
+		return listKind
+	case *Map:
+		return mapKind
+	}
+	return 0
+}
+
+func (k kinds) String() string {
+	switch k {
+	case num:
+		return "number"
+	case ordered:
+		return "number or string"
+	case sized:
+		return "string, list, or map"
+	case sliced:
+		return "string or list"
+	}
+	var a []string
+	for i, name := range []string{"int", "float", "string", "list", "map"} {
+		if k&(1<<uint(i)) != 0 {
+			a = append(a, name)
+		}
+	}
+	return strings.Join(a, " or ")
+}
+
+// resolve returns t, or if t is a bound Var,
+// the type it is bound to, resolved.
+func resolve(t Type) Type {
+	for {
+		v, ok := t.(*Var)
+		if !ok || v.ref == nil {
+			return t
+		}
+		t = v.ref
+	}
+}
+
+// comparable returns whether values of type t
+// may be compared with ==, as in Go: not lists,
+// maps, or funcs, nor records holding them.
+// An unbound Var may stand for any type.
+func comparable(t Type) bool {
+	switch t := resolve(t).(type) {
+	case *List, *Map, *Func:
+		return false
+	case *Record:
+		m, _ := fields(t)
+		for _, u := range m {
+			if !comparable(u) {
+				return false
+			}
+		}
+	case *Data:
+		for _, u := range t.Args {
+			if !comparable(u) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// fields returns all the fields of record r,
+// following its row variables, and its
+// unbound row variable, if any.
+func fields(r *Record) (map[string]Type, *Var) {
+	m := make(map[string]Type)
+	for {
+		for name, t := range r.Fields {
+			m[name] = t
+		}
+		if r.Rest == nil {
+			return m, nil
+		}
+		next, ok := resolve(r.Rest).(*Record)
+		if !ok {
+			return m, resolve(r.Rest).(*Var)
+		}
+		r = next
+	}
+}
+
+// A printer formats types, naming each
+// unbound Var consistently.
+type printer struct {
+	names map[*Var]string
+}
+
+// typeStrings formats the given types with
+// a single printer, so that a Var appearing
+// in more than one of them has the same name.
+func typeStrings(t ...Type) []string {
+	p := &printer{make(map[*Var]string)}
+	var a []string
+	for _, x := range t {
+		a = append(a, p.string(x))
+	}
+	return a
+}
+
+// TypeString returns a string describing t.
+func TypeString(t Type) string {
+	return typeStrings(t)[0]
+}
+
+func (p *printer) string(t Type) string {
+	switch t := resolve(t).(type) {
+	case Basic:
+		return [...]string{"int", "float", "string", "any"}[t]
+	case *List:
+		return "[]" + p.string(t.Elem)
+	case *Map:
+		return "map[" + p.string(t.Key) + "]" + p.string(t.Elem)
+	case *Func:
+		var a []string
+		for i, x := range t.Params {
+			if i == len(t.Params)-1 && t.Variadic {
+				a = append(a, "..."+p.string(resolve(x).(*List).Elem))
+				continue
+			}
+			a = append(a, p.string(x))
+		}
+		return "func(" + strings.Join(a, ", ") + ") " + p.string(t.Result)
+	case Tuple:
+		var a []string
+		for _, x := range t {
+			a = append(a, p.string(x))
+		}
+		return "(" + strings.Join(a, ", ") + ")"
+	case *Record:
+		m, rest := fields(t)
+		var names []string
+		for name := range m {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var a []string
+		for _, name := range names {
+			a = append(a, name+": "+p.string(m[name]))
+		}
+		if rest != nil {
+			a = append(a, "...")
+		}
+		return "{" + strings.Join(a, ", ") + "}"
+	case *Data:
+		if len(t.Args) == 0 {
+			return t.Name
+		}
+		var a []string
+		for _, x := range t.Args {
+			a = append(a, p.string(x))
+		}
+		return t.Name + "[" + strings.Join(a, ", ") + "]"
+	case *Var:
+		if t.kinds != 0 {
+			return t.kinds.String()
+		}
+		name, ok := p.names[t]
+		if !ok {
+			name = string('a' + rune(len(p.names)%26))
+			p.names[t] = name
+		}
+		return name
+	}
+	panic("unreached")
+}
@@ -0,0 +1,376 @@
+package types
+
+import "sort"
+
+// generic is the level of a Var that is
+// quantified in a type scheme. Each use of
+// the scheme gets fresh Vars in its place.
+const generic = 1<<31 - 1
+
+// Type inference uses levels to decide which Vars
+// to generalize, as in OCaml. The checker's level
+// is raised while checking a group of package-level
+// funcs, and each new Var records the level at which
+// it was made. Binding a Var lowers the level of each
+// Var in its type to at most its own, so a Var whose
+// level is still above the checker's, once the group
+// is done, appears nowhere in the environment.
+
+func (c *checker) newVar() *Var {
+	return &Var{level: c.level}
+}
+
+// newKindVar returns a new Var that
+// stands for a type of one of the given kinds.
+func (c *checker) newKindVar(k kinds) *Var {
+	return &Var{kinds: k, level: c.level}
+}
+
+// newIndexVar returns a new Var that stands
+// for a type that can be indexed by key, giving elem.
+func (c *checker) newIndexVar(key, elem Type) *Var {
+	return &Var{kinds: sized, key: key, elem: elem, level: c.level}
+}
+
+// unify makes t and u the same type,
+// binding type variables as needed.
+// It returns false if they cannot be the same.
+// Vars may be left bound even then.
+// Where one is a value's type and the other
+// the type it is used as, t is the value's.
+func (c *checker) unify(t, u Type) bool {
+	t, u = resolve(t), resolve(u)
+	if v, ok := t.(*Var); ok {
+		return c.bind(v, u)
+	}
+	if v, ok := u.(*Var); ok {
+		return c.bind(v, t)
+	}
+	switch t := t.(type) {
+	case Basic:
+		u, ok := u.(Basic)
+		return ok && t == u
+	case *List:
+		u, ok := u.(*List)
+		return ok && c.unify(t.Elem, u.Elem)
+	case *Map:
+		u, ok := u.(*Map)
+		return ok && c.unify(t.Key, u.Key) && c.unify(t.Elem, u.Elem)
+	case *Func:
+		u, ok := u.(*Func)
+		if !ok {
+			return false
+		}
+		if t.Variadic && !u.Variadic {
+			// A variadic func can be used as a func
+			// with any number of params it can take,
+			// but not the other way around.
+			n := len(t.Params) - 1
+			if len(u.Params) < n {
+				return false
+			}
+			elem := resolve(t.Params[n]).(*List).Elem
+			for i, p := range u.Params {
+				q := elem
+				if i < n {
+					q = t.Params[i]
+				}
+				if !c.unify(q, p) {
+					return false
+				}
+			}
+			return c.unify(t.Result, u.Result)
+		}
+		if t.Variadic != u.Variadic || len(t.Params) != len(u.Params) {
+			return false
+		}
+		for i := range t.Params {
+			if !c.unify(t.Params[i], u.Params[i]) {
+				return false
+			}
+		}
+		return c.unify(t.Result, u.Result)
+	case Tuple:
+		u, ok := u.(Tuple)
+		return ok && c.unifyList(t, u)
+	case *Record:
+		u, ok := u.(*Record)
+		return ok && c.unifyRecords(t, u)
+	case *Data:
+		u, ok := u.(*Data)
+		if !ok || t.DataDecl != u.DataDecl {
+			return false
+		}
+		// Inside the package declaring it,
+		// a data type has no arguments.
+		if len(t.Args) != len(u.Args) {
+			return true
+		}
+		return c.unifyList(t.Args, u.Args)
+	}
+	return false
+}
+
+func (c *checker) unifyList(t, u []Type) bool {
+	if len(t) != len(u) {
+		return false
+	}
+	for i := range t {
+		if !c.unify(t[i], u[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// bind binds unbound Var v to t, which is resolved.
+func (c *checker) bind(v *Var, t Type) bool {
+	if w, ok := t.(*Var); ok {
+		if w == v {
+			return true
+		}
+		return c.bindVar(v, w)
+	}
+	if v.row || c.occurs(v, t) {
+		return false
+	}
+	if v.kinds != 0 && v.kinds&kind(t) == 0 {
+		return false
+	}
+	c.adjust(t, v.level)
+	v.ref = t
+	if v.key != nil {
+		var key, elem Type = Int, Int
+		switch t := t.(type) {
+		case *List:
+			elem = t.Elem
+		case *Map:
+			key, elem = t.Key, t.Elem
+		}
+		return c.unify(v.key, key) && c.unify(v.elem, elem)
+	}
+	return true
+}
+
+// bindVar binds unbound Var v to unbound Var w,
+// restricting w to the kinds of types v may stand for.
+func (c *checker) bindVar(v, w *Var) bool {
+	if v.row != w.row {
+		return false
+	}
+	if v.kinds != 0 {
+		if w.kinds != 0 && v.kinds&w.kinds == 0 {
+			return false
+		}
+		if w.kinds == 0 {
+			w.kinds = v.kinds
+		} else {
+			w.kinds &= v.kinds
+		}
+	}
+	if v.level < w.level {
+		c.adjust(w, v.level)
+	}
+	v.ref = w
+	if v.key != nil {
+		if w.key == nil {
+			w.key, w.elem = v.key, v.elem
+			return true
+		}
+		return c.unify(v.key, w.key) && c.unify(v.elem, w.elem)
+	}
+	return true
+}
+
+// unifyRecords unifies the fields of r and s.
+// A field in only one of them must be in
+// the fields the other's row variable stands for.
+func (c *checker) unifyRecords(r, s *Record) bool {
+	rf, rrest := fields(r)
+	sf, srest := fields(s)
+	onlyR := make(map[string]Type)
+	for _, name := range sortedNames(rf) {
+		u, ok := sf[name]
+		if !ok {
+			onlyR[name] = rf[name]
+			continue
+		}
+		if !c.unify(rf[name], u) {
+			return false
+		}
+	}
+	onlyS := make(map[string]Type)
+	for name, t := range sf {
+		if _, ok := rf[name]; !ok {
+			onlyS[name] = t
+		}
+	}
+	switch {
+	case rrest == nil && len(onlyS) > 0, srest == nil && len(onlyR) > 0:
+		return false
+	case rrest != nil && rrest == srest:
+		return len(onlyR) == 0 && len(onlyS) == 0
+	}
+	var rest *Var
+	if rrest != nil && srest != nil {
+		rest = &Var{row: true, level: rrest.level}
+		if srest.level < rest.level {
+			rest.level = srest.level
+		}
+	}
+	if srest != nil {
+		if !c.bindRow(srest, &Record{onlyR, rest}) {
+			return false
+		}
+	}
+	if rrest != nil {
+		return c.bindRow(rrest, &Record{onlyS, rest})
+	}
+	return true
+}
+
+// bindRow binds row variable v to the fields of r.
+func (c *checker) bindRow(v *Var, r *Record) bool {
+	if c.occurs(v, r) {
+		return false
+	}
+	c.adjust(r, v.level)
+	v.ref = r
+	return true
+}
+
+func sortedNames(m map[string]Type) []string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// walk calls f for each unbound Var in t,
+// including those in the key and elem of each Var.
+func walk(t Type, f func(*Var)) {
+	switch t := resolve(t).(type) {
+	case *List:
+		walk(t.Elem, f)
+	case *Map:
+		walk(t.Key, f)
+		walk(t.Elem, f)
+	case *Func:
+		for _, x := range t.Params {
+			walk(x, f)
+		}
+		walk(t.Result, f)
+	case Tuple:
+		for _, x := range t {
+			walk(x, f)
+		}
+	case *Record:
+		m, rest := fields(t)
+		for _, name := range sortedNames(m) {
+			walk(m[name], f)
+		}
+		if rest != nil {
+			f(rest)
+		}
+	case *Data:
+		for _, x := range t.Args {
+			walk(x, f)
+		}
+	case *Var:
+		f(t)
+		if t.key != nil {
+			walk(t.key, f)
+			walk(t.elem, f)
+		}
+	}
+}
+
+// occurs returns whether v appears in t.
+func (c *checker) occurs(v *Var, t Type) bool {
+	found := false
+	walk(t, func(w *Var) {
+		if w == v {
+			found = true
+		}
+	})
+	return found
+}
+
+// adjust lowers the level of each Var in t
+// to at most level.
+func (c *checker) adjust(t Type, level int) {
+	walk(t, func(v *Var) {
+		if v.level > level {
+			v.level = level
+		}
+	})
+}
+
+// generalize quantifies each Var in t
+// made at a level above the checker's.
+func (c *checker) generalize(t Type) {
+	walk(t, func(v *Var) {
+		if v.level > c.level {
+			v.level = generic
+		}
+	})
+}
+
+// instantiate returns a copy of t, with fresh Vars
+// in place of its quantified ones.
+func (c *checker) instantiate(t Type) Type {
+	return c.subst(t, make(map[*Var]*Var))
+}
+
+func (c *checker) subst(t Type, m map[*Var]*Var) Type {
+	switch t := resolve(t).(type) {
+	case *List:
+		return &List{c.subst(t.Elem, m)}
+	case *Map:
+		return &Map{c.subst(t.Key, m), c.subst(t.Elem, m)}
+	case *Func:
+		f := &Func{Variadic: t.Variadic, Result: c.subst(t.Result, m)}
+		for _, x := range t.Params {
+			f.Params = append(f.Params, c.subst(x, m))
+		}
+		return f
+	case Tuple:
+		var u Tuple
+		for _, x := range t {
+			u = append(u, c.subst(x, m))
+		}
+		return u
+	case *Record:
+		fm, rest := fields(t)
+		r := &Record{Fields: make(map[string]Type)}
+		for name, x := range fm {
+			r.Fields[name] = c.subst(x, m)
+		}
+		if rest != nil {
+			r.Rest = c.subst(rest, m).(*Var)
+		}
+		return r
+	case *Data:
+		d := &Data{DataDecl: t.DataDecl}
+		for _, x := range t.Args {
+			d.Args = append(d.Args, c.subst(x, m))
+		}
+		return d
+	case *Var:
+		if t.level != generic {
+			return t
+		}
+		if v := m[t]; v != nil {
+			return v
+		}
+		v := &Var{kinds: t.kinds, row: t.row, level: c.level}
+		m[t] = v
+		if t.key != nil {
+			v.key, v.elem = c.subst(t.key, m), c.subst(t.elem, m)
+		}
+		return v
+	}
+	return t
+}
@@ -0,0 +1,45 @@
+package types
+
+// universe holds the builtin names.
+// Their types are schemes, each of whose
+// Vars is quantified.
+var universe = newScope(nil)
+
+func init() {
+	q := func(k kinds) *Var {
+		return &Var{kinds: k, level: generic}
+	}
+	fn := func(result Type, params ...Type) *Func {
+		return &Func{Params: params, Result: result}
+	}
+	a, b := q(0), q(0)
+	n := q(num)
+	c := q(0)
+	c.kinds, c.key, c.elem = sized, q(0), q(0)
+	key, elem := q(0), q(0)
+	m := &Map{key, elem}
+
+	builtins := map[string]Type{
+		"false":      Int,
+		"true":       Int,
+		"nil":        Any,
+		"println":    nil, // see checker.call
+		"callcc":     fn(a, fn(a, fn(b, a))),
+		"panic":      fn(b, a),
+		"recover":    fn(Any),
+		"len":        fn(Int, c),
+		"append":     &Func{Params: []Type{&List{a}, &List{a}}, Variadic: true, Result: &List{a}},
+		"delete":     fn(b, m, key),
+		"keys":       fn(&List{key}, m),
+		"int":        fn(Int, n),
+		"float":      fn(Float, n),
+		"string":     fn(String, Int),
+		"itoa":       fn(String, Int),
+		"atoi":       fn(Tuple{Int, Int}, String),
+		"runes":      fn(&List{Int}, String),
+		"decoderune": fn(Tuple{Int, Int}, String, Int),
+	}
+	for name, t := range builtins {
+		universe.objs[name] = &object{kind: builtinObj, typ: t}
+	}
+}